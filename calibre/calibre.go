@@ -0,0 +1,157 @@
+// Package calibre reads book metadata from a Calibre library's
+// metadata.db. The database is opened read-only, so a library can be read
+// while Calibre itself is running. Values are returned the way Calibre
+// stores them; mapping them onto our books is up to the caller.
+package calibre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Book is one book of a Calibre library
+type Book struct {
+	ID          int
+	Title       string
+	Authors     []string
+	Series      string
+	SeriesIndex float64
+	Tags        []string
+	ISBN        string
+
+	// Languages are ISO 639-2 codes such as "eng", in Calibre's order
+	Languages []string
+
+	// Rating counts half stars from 0 to 10; 0 means unrated
+	Rating int
+
+	Publisher string
+
+	// PublicationYear is 0 when Calibre doesn't know it
+	PublicationYear int
+
+	// Added is the date the book was added to Calibre, as YYYY-MM-DD
+	Added string
+
+	// Formats are the files in the library, such as EPUB or M4B
+	Formats []string
+}
+
+// undefinedYear is the year of Calibre's "no date" placeholder,
+// 0101-01-01
+const undefinedYear = 101
+
+// bookQuery selects books with their single-valued fields. Calibre
+// keeps series, publishers and ratings in link tables too, but a book has
+// at most one of each.
+const bookQuery = `
+	SELECT
+		b.id, b.title, COALESCE(b.series_index, 0),
+		COALESCE(date(b.timestamp), ''),
+		COALESCE(CAST(strftime('%Y', b.pubdate) AS INTEGER), 0),
+		COALESCE((SELECT s.name FROM books_series_link l JOIN series s ON s.id = l.series
+			WHERE l.book = b.id), ''),
+		COALESCE((SELECT p.name FROM books_publishers_link l JOIN publishers p ON p.id = l.publisher
+			WHERE l.book = b.id), ''),
+		COALESCE((SELECT r.rating FROM books_ratings_link l JOIN ratings r ON r.id = l.rating
+			WHERE l.book = b.id), 0),
+		COALESCE((SELECT i.val FROM identifiers i WHERE i.book = b.id AND i.type = 'isbn'), '')
+	FROM books b
+	ORDER BY b.id
+`
+
+// Read loads every book of the library whose metadata.db is at path
+func Read(ctx context.Context, path string) ([]Book, error) {
+	// mode=ro fails late and vaguely on a missing file; check it first
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open Calibre library: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro&_query_only=true")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Calibre library: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, bookQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query books; is %s a Calibre metadata.db? %w", path, err)
+	}
+	defer rows.Close()
+
+	var books []Book
+	index := make(map[int]int)
+	for rows.Next() {
+		var b Book
+		err := rows.Scan(&b.ID, &b.Title, &b.SeriesIndex, &b.Added, &b.PublicationYear,
+			&b.Series, &b.Publisher, &b.Rating, &b.ISBN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan book: %w", err)
+		}
+		if b.PublicationYear <= undefinedYear {
+			b.PublicationYear = 0
+		}
+		index[b.ID] = len(books)
+		books = append(books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate books: %w", err)
+	}
+	rows.Close()
+
+	lists := []struct {
+		query string
+		field func(b *Book) *[]string
+	}{
+		{`SELECT l.book, a.name FROM books_authors_link l JOIN authors a ON a.id = l.author ORDER BY l.book, l.id`,
+			func(b *Book) *[]string { return &b.Authors }},
+		{`SELECT l.book, t.name FROM books_tags_link l JOIN tags t ON t.id = l.tag ORDER BY l.book, t.name`,
+			func(b *Book) *[]string { return &b.Tags }},
+		{`SELECT l.book, g.lang_code FROM books_languages_link l JOIN languages g ON g.id = l.lang_code ORDER BY l.book, l.item_order`,
+			func(b *Book) *[]string { return &b.Languages }},
+		{`SELECT book, format FROM data ORDER BY book, format`,
+			func(b *Book) *[]string { return &b.Formats }},
+	}
+	for _, list := range lists {
+		if err := loadList(ctx, db, list.query, books, index, list.field); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range books {
+		// Calibre stores the commas of "Last, First" names as pipes
+		for j, name := range books[i].Authors {
+			books[i].Authors[j] = strings.ReplaceAll(name, "|", ",")
+		}
+	}
+
+	return books, nil
+}
+
+// loadList appends the (book, value) rows of query to the list field
+// picks out of each book
+func loadList(ctx context.Context, db *sql.DB, query string, books []Book, index map[int]int, field func(b *Book) *[]string) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query Calibre library: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var value string
+		if err := rows.Scan(&bookID, &value); err != nil {
+			return fmt.Errorf("failed to scan Calibre library: %w", err)
+		}
+		if i, ok := index[bookID]; ok {
+			list := field(&books[i])
+			*list = append(*list, value)
+		}
+	}
+	return rows.Err()
+}
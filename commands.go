@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/favxlaw/calibre"
+	"github.com/favxlaw/config"
+	"github.com/favxlaw/importer"
+	"github.com/favxlaw/models"
+	"github.com/favxlaw/store"
+)
+
+// command is a subcommand run instead of the server
+type command struct {
+	usage string
+	run   func(cfg *config.Config, args []string) error
+}
+
+const (
+	importCalibreUsage = "[-dry-run] path/to/metadata.db"
+	backupUsage        = "[-dir dir] [-keep n]"
+	restoreUsage       = "path/to/snapshot.db"
+)
+
+var commands = map[string]command{
+	"import-calibre": {importCalibreUsage, importCalibre},
+	"backup":         {backupUsage, backup},
+	"restore":        {restoreUsage, restore},
+}
+
+// runCommand runs the subcommand named by args[0] and returns the exit
+// status
+func runCommand(cfg *config.Config, args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	if err := cmd.run(cfg, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// printUsage lists the subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  booktracker              start the server")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  booktracker %s %s\n", name, commands[name].usage)
+	}
+}
+
+// importCalibre imports a Calibre library into the database and prints
+// how each Calibre book was mapped
+func importCalibre(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import-calibre", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would change without saving")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: import-calibre %s", importCalibreUsage)
+	}
+
+	ctx := context.Background()
+	books, err := calibre.Read(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	bookStore, err := store.NewSQLiteStore(cfg.DBPath, cfg.CoverDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer bookStore.Close()
+
+	report, err := importer.Calibre(ctx, bookStore, books, *dryRun)
+	if err != nil {
+		return err
+	}
+	printCalibreReport(os.Stdout, report)
+
+	if report.Rejected > 0 {
+		return fmt.Errorf("%d books rejected, nothing was imported", report.Rejected)
+	}
+	return nil
+}
+
+// printCalibreReport prints one line per Calibre book, its mapping notes
+// indented below it, then the totals
+func printCalibreReport(w io.Writer, report importer.CalibreReport) {
+	for _, row := range report.Rows {
+		target := "new book"
+		if row.BookID != 0 {
+			target = fmt.Sprintf("book %d", row.BookID)
+		}
+		if row.Action == models.ImportRejected {
+			target = "-"
+		}
+		fmt.Fprintf(w, "calibre %-5d -> %-10s %-8s %s\n", row.Line, target, row.Action, row.Title)
+		if row.Error != "" {
+			fmt.Fprintf(w, "    error: %s\n", row.Error)
+		}
+		for _, note := range report.Notes[row.Line] {
+			fmt.Fprintf(w, "    note: %s\n", note)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d created, %d updated, %d skipped, %d rejected\n",
+		report.Created, report.Updated, report.Skipped, report.Rejected)
+	if report.DryRun {
+		fmt.Fprintln(w, "Dry run: nothing was saved")
+	}
+}
+
+// backup writes a snapshot of the database, like POST /admin/backup
+func backup(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	dir := flags.String("dir", cfg.BackupDir, "directory to write the snapshot to")
+	keep := flags.Int("keep", cfg.BackupKeep, "days of snapshots to keep, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *keep < 0 {
+		return fmt.Errorf("usage: backup %s", backupUsage)
+	}
+
+	bookStore, err := store.NewSQLiteStore(cfg.DBPath, cfg.CoverDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer bookStore.Close()
+
+	result, err := bookStore.Backup(context.Background(), *dir, *keep)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s (%d bytes, schema version %d)\n",
+		filepath.Join(*dir, result.Name), result.Size, result.SchemaVersion)
+	for _, name := range result.Pruned {
+		fmt.Printf("Removed %s\n", name)
+	}
+	return nil
+}
+
+// restore swaps a snapshot in for the database and migrates it to the
+// current schema. The server must not be running.
+func restore(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: restore %s", restoreUsage)
+	}
+
+	restored, previous, err := store.Restore(context.Background(), cfg.DBPath, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Opening the store applies any migrations the snapshot predates
+	bookStore, err := store.NewSQLiteStore(cfg.DBPath, cfg.CoverDir)
+	if err != nil {
+		return fmt.Errorf("restored %s but failed to open it: %w", restored.Name, err)
+	}
+	bookStore.Close()
+
+	fmt.Printf("Restored %s (schema version %d) to %s\n", restored.Name, restored.SchemaVersion, cfg.DBPath)
+	if previous != "" {
+		fmt.Printf("The previous database was kept as %s\n", previous)
+	}
+	return nil
+}
@@ -10,15 +10,31 @@ type Config struct {
 	Port     string
 	DBPath   string
 	LogLevel string
+
+	// CoverDir is where uploaded cover images and thumbnails are stored
+	CoverDir string
+
+	// BackupDir is where database snapshots are written, and BackupKeep
+	// how many days of them are kept; 0 keeps them all
+	BackupDir  string
+	BackupKeep int
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
-		Port:     getEnv("PORT", "8006"),
-		DBPath:   getEnv("DB_PATH", "./booktracker.db"),
-		LogLevel: getEnv("LOG_LEVEL", "info"),
+		Port:      getEnv("PORT", "8006"),
+		DBPath:    getEnv("DB_PATH", "./booktracker.db"),
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		CoverDir:  getEnv("COVER_DIR", "./uploads/covers"),
+		BackupDir: getEnv("BACKUP_DIR", "./backups"),
 	}
 
+	keep, err := strconv.Atoi(getEnv("BACKUP_KEEP", "7"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: BACKUP_KEEP must be a number, got: %s", os.Getenv("BACKUP_KEEP"))
+	}
+	cfg.BackupKeep = keep
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -43,6 +59,18 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("DB_PATH cannot be empty")
 	}
 
+	if c.CoverDir == "" {
+		return fmt.Errorf("COVER_DIR cannot be empty")
+	}
+
+	if c.BackupDir == "" {
+		return fmt.Errorf("BACKUP_DIR cannot be empty")
+	}
+
+	if c.BackupKeep < 0 {
+		return fmt.Errorf("BACKUP_KEEP cannot be negative, got: %d", c.BackupKeep)
+	}
+
 	validLogLevels := map[string]bool{
 		"debug": true,
 		"info":  true,
@@ -0,0 +1,125 @@
+// Package covers checks uploaded cover images and renders their
+// thumbnails. Everything here is pure Go: the standard library decodes
+// JPEG and PNG, golang.org/x/image decodes WebP and does the scaling.
+// Thumbnails are always JPEG, so they are small and render everywhere.
+package covers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Original names the uploaded file among a cover's files
+const Original = "original"
+
+// Thumbnail widths by size name. Thumbnails keep the aspect ratio of the
+// original and are never scaled up.
+var Sizes = map[string]int{
+	"small":  150,
+	"medium": 300,
+	"large":  600,
+}
+
+// ContentTypes lists the accepted upload types with the image format
+// name the decoders report for them
+var ContentTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// maxPixels caps the decoded size so a small file can't expand into an
+// enormous bitmap. 16 MP is far more than any cover needs and still
+// decodes to 64 MB of RGBA at most.
+const maxPixels = 16_000_000
+
+// thumbnailQuality is the JPEG quality of thumbnails
+const thumbnailQuality = 85
+
+// ErrUnsupported is returned for data that is not an accepted image, or
+// not of its declared type
+var ErrUnsupported = errors.New("cover must be a JPEG, PNG or WebP image")
+
+// Set is an uploaded cover with its rendered thumbnails
+type Set struct {
+	ContentType string
+	Width       int
+	Height      int
+
+	// Files holds the original under Original and a JPEG per size name
+	Files map[string][]byte
+}
+
+// Process checks that data is an image of the declared content type and
+// renders a thumbnail for every size
+func Process(data []byte, contentType string) (Set, error) {
+	format, ok := ContentTypes[contentType]
+	if !ok {
+		return Set{}, ErrUnsupported
+	}
+	if sniffed := http.DetectContentType(data); sniffed != contentType {
+		return Set{}, fmt.Errorf("cover content is %s, not %s: %w", sniffed, contentType, ErrUnsupported)
+	}
+
+	config, decodedFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decodedFormat != format {
+		return Set{}, ErrUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return Set{}, fmt.Errorf("cover is %dx%d pixels, which is too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Set{}, fmt.Errorf("failed to decode cover: %w", err)
+	}
+
+	set := Set{
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		Files:       map[string][]byte{Original: data},
+	}
+
+	for name, width := range Sizes {
+		thumb, err := thumbnail(img, width)
+		if err != nil {
+			return Set{}, fmt.Errorf("failed to render %s thumbnail: %w", name, err)
+		}
+		set.Files[name] = thumb
+	}
+
+	return set, nil
+}
+
+// thumbnail scales img down to width and encodes it as JPEG.
+// Transparent areas are flattened onto white, since JPEG has no alpha.
+func thumbnail(img image.Image, width int) ([]byte, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+// Package filterql parses the filter and sort query parameters of list
+// endpoints, e.g.
+//
+//	status:in(reading,to_read) AND author:~"herbert" AND end_date>=2025-01-01
+//
+// into an AST. It knows nothing about SQL or which fields exist; the store
+// validates field names and compiles the tree into a parameterized query.
+package filterql
+
+// Expr is a node of a parsed filter expression
+type Expr interface {
+	exprNode()
+}
+
+// Logical joins two expressions with AND or OR
+type Logical struct {
+	Op    string // "AND" or "OR"
+	Left  Expr
+	Right Expr
+}
+
+// Not negates an expression
+type Not struct {
+	Expr Expr
+}
+
+// Comparison tests a single field against one or more values
+type Comparison struct {
+	Field  string
+	Op     Operator
+	Values []string
+
+	// Pos and ValuePos are byte offsets of the field name and of each
+	// value, used for error reporting
+	Pos      int
+	ValuePos []int
+}
+
+func (*Logical) exprNode()    {}
+func (*Not) exprNode()        {}
+func (*Comparison) exprNode() {}
+
+// Operator is a comparison operator
+type Operator string
+
+const (
+	OpEq       Operator = ":"
+	OpNe       Operator = "!="
+	OpContains Operator = ":~"
+	OpIn       Operator = "in"
+	OpGt       Operator = ">"
+	OpGe       Operator = ">="
+	OpLt       Operator = "<"
+	OpLe       Operator = "<="
+)
+
+// SortField is one key of a multi-field sort
+type SortField struct {
+	Field string
+	Desc  bool
+
+	// Pos is the byte offset of the field in the sort parameter
+	Pos int
+}
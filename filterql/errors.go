@@ -0,0 +1,19 @@
+package filterql
+
+import "fmt"
+
+// Error describes a filter or sort parameter that could not be used.
+// Pos is the byte offset of Token within the parameter value.
+type Error struct {
+	Param   string
+	Pos     int
+	Token   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s: %s at position %d", e.Param, e.Message, e.Pos)
+	}
+	return fmt.Sprintf("%s: %s at position %d near %q", e.Param, e.Message, e.Pos, e.Token)
+}
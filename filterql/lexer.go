@@ -0,0 +1,114 @@
+package filterql
+
+import "strings"
+
+// tokenKind classifies lexer tokens
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token is a lexeme and the byte offset it started at
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value string // unquoted text of words and strings
+}
+
+// operators are matched longest first
+var operators = []string{":~", "!=", ">=", "<=", ":", ">", "<", "="}
+
+// lex splits a filter expression into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+
+		case c == '"':
+			value, end, ok := lexString(input, i)
+			if !ok {
+				return nil, &Error{Param: "filter", Pos: i, Token: input[i:], Message: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: input[i:end], pos: i, value: value})
+			i = end
+
+		case strings.ContainsRune(":!<>=~", rune(c)):
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(input[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &Error{Param: "filter", Pos: i, Token: string(c), Message: "unexpected character"}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+
+		default:
+			start := i
+			for i < len(input) && isWordChar(input[i]) {
+				i++
+			}
+			if i == start {
+				return nil, &Error{Param: "filter", Pos: i, Token: string(c), Message: "unexpected character"}
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[start:i], pos: start, value: input[start:i]})
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(input)})
+	return tokens, nil
+}
+
+// lexString reads a double-quoted string starting at start, where \" and
+// \\ are the only escapes. It returns the unquoted value and end offset.
+func lexString(input string, start int) (string, int, bool) {
+	var value strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 < len(input) {
+				i++
+				value.WriteByte(input[i])
+			}
+		case '"':
+			return value.String(), i + 1, true
+		default:
+			value.WriteByte(input[i])
+		}
+	}
+	return "", 0, false
+}
+
+// isWordChar reports whether c can appear in an unquoted word. Bytes of
+// multi-byte UTF-8 characters are always word characters.
+func isWordChar(c byte) bool {
+	return !strings.ContainsRune(" \t\n\r()\",:!<>=~", rune(c))
+}
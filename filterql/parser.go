@@ -0,0 +1,252 @@
+package filterql
+
+import "strings"
+
+// maxDepth bounds nesting so hostile input can't exhaust the stack
+const maxDepth = 32
+
+// parser is a recursive descent parser over lexed tokens:
+//
+//	expr       = and { "OR" and }
+//	and        = unary { "AND" unary }
+//	unary      = "NOT" unary | "(" expr ")" | comparison
+//	comparison = field op value | field ":in(" value { "," value } ")"
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// Parse parses a filter expression. An empty input yields a nil Expr.
+func Parse(input string) (Expr, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorAt(tok, "expected AND, OR or end of filter")
+	}
+	return expr, nil
+}
+
+// ParseSort parses a comma-separated sort list such as "-end_date,title",
+// where a leading "-" sorts that field in descending order
+func ParseSort(input string) ([]SortField, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	offset := 0
+	for _, part := range strings.Split(input, ",") {
+		pos := offset + len(part) - len(strings.TrimLeft(part, " "))
+		offset += len(part) + 1
+
+		name := strings.TrimSpace(part)
+		desc := false
+		if strings.HasPrefix(name, "-") {
+			desc, name = true, name[1:]
+		} else if strings.HasPrefix(name, "+") {
+			name = name[1:]
+		}
+
+		if name == "" || strings.IndexFunc(name, func(r rune) bool { return !isIdentRune(r) }) >= 0 {
+			return nil, &Error{Param: "sort", Pos: pos, Token: strings.TrimSpace(part), Message: "invalid sort field"}
+		}
+
+		fields = append(fields, SortField{Field: name, Desc: desc, Pos: pos})
+	}
+	return fields, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorAt(p.peek(), "filter is nested too deeply")
+	}
+
+	if p.isKeyword("NOT") {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, p.errorAt(tok, "expected )")
+		}
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	field := p.next()
+	if field.kind != tokenWord || p.isReserved(field) || strings.IndexFunc(field.text, func(r rune) bool { return !isIdentRune(r) }) >= 0 {
+		return nil, p.errorAt(field, "expected field name")
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokenOperator {
+		return nil, p.errorAt(opTok, "expected operator after field")
+	}
+
+	op := Operator(opTok.text)
+	if opTok.text == "=" {
+		op = OpEq
+	}
+
+	// field:in(a,b,c)
+	if op == OpEq && strings.EqualFold(p.peek().text, "in") && p.peekAt(1).kind == tokenLParen {
+		p.next()
+		p.next()
+		cmp := &Comparison{Field: field.text, Op: OpIn, Pos: field.pos}
+		if err := p.parseValueList(cmp); err != nil {
+			return nil, err
+		}
+		return cmp, nil
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{
+		Field:    field.text,
+		Op:       op,
+		Values:   []string{value.value},
+		Pos:      field.pos,
+		ValuePos: []int{value.pos},
+	}, nil
+}
+
+// parseValueList reads values into cmp up to and including the closing paren
+func (p *parser) parseValueList(cmp *Comparison) error {
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		cmp.Values = append(cmp.Values, value.value)
+		cmp.ValuePos = append(cmp.ValuePos, value.pos)
+
+		switch tok := p.next(); tok.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return nil
+		default:
+			return p.errorAt(tok, "expected , or )")
+		}
+	}
+}
+
+func (p *parser) parseValue() (token, error) {
+	tok := p.next()
+	if tok.kind != tokenWord && tok.kind != tokenString {
+		return tok, p.errorAt(tok, "expected value")
+	}
+	return tok, nil
+}
+
+// peek returns the current token without consuming it
+func (p *parser) peek() token {
+	return p.peekAt(0)
+}
+
+// peekAt looks ahead n tokens, returning EOF past the end
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+// next consumes and returns the current token
+func (p *parser) next() token {
+	tok := p.peek()
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return tok
+}
+
+// isKeyword reports whether the current token is the given bare keyword
+func (p *parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, keyword)
+}
+
+// isReserved reports whether a word token is a logical keyword
+func (p *parser) isReserved(tok token) bool {
+	return strings.EqualFold(tok.text, "AND") || strings.EqualFold(tok.text, "OR") || strings.EqualFold(tok.text, "NOT")
+}
+
+// errorAt builds a syntax error pointing at tok
+func (p *parser) errorAt(tok token, message string) error {
+	text := tok.text
+	if tok.kind == tokenEOF {
+		message += ", got end of filter"
+	}
+	return &Error{Param: "filter", Pos: tok.pos, Token: text, Message: message}
+}
+
+// isIdentRune reports whether r can appear in a field name
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,234 @@
+package filterql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// render prints an expression as an S-expression, so tests can check the
+// shape of the tree without spelling out every node
+func render(expr Expr) string {
+	switch node := expr.(type) {
+	case *Logical:
+		return "(" + node.Op + " " + render(node.Left) + " " + render(node.Right) + ")"
+	case *Not:
+		return "(NOT " + render(node.Expr) + ")"
+	case *Comparison:
+		return node.Field + string(node.Op) + strings.Join(node.Values, "|")
+	case nil:
+		return "<nil>"
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"a:1", "a:1"},
+		{"a:1 AND b:2 AND c:3", "(AND (AND a:1 b:2) c:3)"},
+		{"a:1 OR b:2 OR c:3", "(OR (OR a:1 b:2) c:3)"},
+		{"a:1 OR b:2 AND c:3", "(OR a:1 (AND b:2 c:3))"},
+		{"a:1 AND b:2 OR c:3", "(OR (AND a:1 b:2) c:3)"},
+		{"a:1 AND (b:2 OR c:3)", "(AND a:1 (OR b:2 c:3))"},
+		{"NOT a:1 AND b:2", "(AND (NOT a:1) b:2)"},
+		{"NOT (a:1 AND b:2)", "(NOT (AND a:1 b:2))"},
+		{"NOT NOT a:1", "(NOT (NOT a:1))"},
+		{"a:1 or b:2 and not c:3", "(OR a:1 (AND b:2 (NOT c:3)))"},
+		{"((a:1))", "a:1"},
+		{"", "<nil>"},
+		{"   ", "<nil>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if got := render(expr); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseComparison(t *testing.T) {
+	tests := []struct {
+		input string
+		want  *Comparison
+	}{
+		{`title:Dune`, &Comparison{Field: "title", Op: OpEq, Values: []string{"Dune"}, Pos: 0, ValuePos: []int{6}}},
+		{`title=Dune`, &Comparison{Field: "title", Op: OpEq, Values: []string{"Dune"}, Pos: 0, ValuePos: []int{6}}},
+		{`status!=read`, &Comparison{Field: "status", Op: OpNe, Values: []string{"read"}, Pos: 0, ValuePos: []int{8}}},
+		{`author:~herbert`, &Comparison{Field: "author", Op: OpContains, Values: []string{"herbert"}, Pos: 0, ValuePos: []int{8}}},
+		{`author :~ "frank herbert"`, &Comparison{Field: "author", Op: OpContains, Values: []string{"frank herbert"}, Pos: 0, ValuePos: []int{10}}},
+		{`rating>=4.5`, &Comparison{Field: "rating", Op: OpGe, Values: []string{"4.5"}, Pos: 0, ValuePos: []int{8}}},
+		{`rating<3`, &Comparison{Field: "rating", Op: OpLt, Values: []string{"3"}, Pos: 0, ValuePos: []int{7}}},
+		{`end_date>-30d`, &Comparison{Field: "end_date", Op: OpGt, Values: []string{"-30d"}, Pos: 0, ValuePos: []int{9}}},
+		{`page_count<=300`, &Comparison{Field: "page_count", Op: OpLe, Values: []string{"300"}, Pos: 0, ValuePos: []int{12}}},
+		{`title:"say \"hi\" \\ bye"`, &Comparison{Field: "title", Op: OpEq, Values: []string{`say "hi" \ bye`}, Pos: 0, ValuePos: []int{6}}},
+		{`title:"AND"`, &Comparison{Field: "title", Op: OpEq, Values: []string{"AND"}, Pos: 0, ValuePos: []int{6}}},
+		{`title:Dune_Messiah`, &Comparison{Field: "title", Op: OpEq, Values: []string{"Dune_Messiah"}, Pos: 0, ValuePos: []int{6}}},
+		{`title:Über`, &Comparison{Field: "title", Op: OpEq, Values: []string{"Über"}, Pos: 0, ValuePos: []int{6}}},
+		{`status:in(reading,to_read)`, &Comparison{Field: "status", Op: OpIn, Values: []string{"reading", "to_read"}, Pos: 0, ValuePos: []int{10, 18}}},
+		{`status:IN( reading , "to read" )`, &Comparison{Field: "status", Op: OpIn, Values: []string{"reading", "to read"}, Pos: 0, ValuePos: []int{11, 21}}},
+		{`id:in(7)`, &Comparison{Field: "id", Op: OpIn, Values: []string{"7"}, Pos: 0, ValuePos: []int{6}}},
+		{`title:in`, &Comparison{Field: "title", Op: OpEq, Values: []string{"in"}, Pos: 0, ValuePos: []int{6}}},
+		{`  series.name:x`, &Comparison{Field: "series.name", Op: OpEq, Values: []string{"x"}, Pos: 2, ValuePos: []int{14}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if !reflect.DeepEqual(expr, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, expr, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		token   string
+		message string
+	}{
+		{`title:"Dune`, 6, `"Dune`, "unterminated string"},
+		{`title ~ Dune`, 6, "~", "unexpected character"},
+		{`title:`, 6, "", "expected value, got end of filter"},
+		{`title:(Dune)`, 6, "(", "expected value"},
+		{`:Dune`, 0, ":", "expected field name"},
+		{`AND:1`, 0, "AND", "expected field name"},
+		{`"title":Dune`, 0, `"title"`, "expected field name"},
+		{`ti-tle:Dune`, 0, "ti-tle", "expected field name"},
+		{`title "Dune"`, 6, `"Dune"`, "expected operator after field"},
+		{`title`, 5, "", "expected operator after field, got end of filter"},
+		{`a:1 b:2`, 4, "b", "expected AND, OR or end of filter"},
+		{`a:1 & b:2`, 4, "&", "expected AND, OR or end of filter"},
+		{`a:1)`, 3, ")", "expected AND, OR or end of filter"},
+		{`a:1 AND`, 7, "", "expected field name, got end of filter"},
+		{`a:1 OR OR b:2`, 7, "OR", "expected field name"},
+		{`NOT`, 3, "", "expected field name, got end of filter"},
+		{`(a:1`, 4, "", "expected ), got end of filter"},
+		{`(a:1 b:2)`, 5, "b", "expected )"},
+		{`status:in(a,b`, 13, "", "expected , or ), got end of filter"},
+		{`status:in(a b)`, 12, "b", "expected , or )"},
+		{`status:in(a,)`, 12, ")", "expected value"},
+		{`status:in()`, 10, ")", "expected value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var filterErr *Error
+			if !errors.As(err, &filterErr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.input, err)
+			}
+			want := &Error{Param: "filter", Pos: tt.pos, Token: tt.token, Message: tt.message}
+			if *filterErr != *want {
+				t.Errorf("Parse(%q) error = %+v, want %+v", tt.input, *filterErr, *want)
+			}
+		})
+	}
+}
+
+func TestParseMaxDepth(t *testing.T) {
+	nested := func(n int) string {
+		return strings.Repeat("(", n) + "a:1" + strings.Repeat(")", n)
+	}
+
+	// The comparison itself takes one level
+	if _, err := Parse(nested(maxDepth - 1)); err != nil {
+		t.Errorf("Parse rejected %d levels of parentheses: %v", maxDepth-1, err)
+	}
+
+	_, err := Parse(nested(maxDepth))
+	var filterErr *Error
+	if !errors.As(err, &filterErr) || filterErr.Message != "filter is nested too deeply" || filterErr.Pos != maxDepth {
+		t.Errorf("Parse accepted %d levels of parentheses: %v", maxDepth, err)
+	}
+
+	if _, err := Parse(strings.Repeat("NOT ", maxDepth) + "a:1"); err == nil {
+		t.Errorf("Parse accepted %d nested NOTs", maxDepth)
+	}
+
+	// Far deeper input must fail cleanly rather than exhaust the stack
+	if _, err := Parse(nested(100000)); err == nil {
+		t.Error("Parse accepted 100000 levels of parentheses")
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{&Error{Param: "filter", Pos: 4, Token: "b", Message: "expected )"}, `filter: expected ) at position 4 near "b"`},
+		{&Error{Param: "filter", Pos: 6, Message: "expected value, got end of filter"}, "filter: expected value, got end of filter at position 6"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []SortField
+	}{
+		{"", nil},
+		{"title", []SortField{{Field: "title", Pos: 0}}},
+		{"-end_date,title", []SortField{{Field: "end_date", Desc: true, Pos: 0}, {Field: "title", Pos: 10}}},
+		{"+rating, -id", []SortField{{Field: "rating", Pos: 0}, {Field: "id", Desc: true, Pos: 9}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSort(tt.input)
+		if err != nil {
+			t.Errorf("ParseSort(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSort(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseSortErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		token string
+	}{
+		{"title,,id", 6, ""},
+		{"title, -", 7, "-"},
+		{"title desc", 0, "title desc"},
+		{"id,title;drop", 3, "title;drop"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseSort(tt.input)
+		var filterErr *Error
+		if !errors.As(err, &filterErr) {
+			t.Errorf("ParseSort(%q) error = %v, want *Error", tt.input, err)
+			continue
+		}
+		want := &Error{Param: "sort", Pos: tt.pos, Token: tt.token, Message: "invalid sort field"}
+		if *filterErr != *want {
+			t.Errorf("ParseSort(%q) error = %+v, want %+v", tt.input, *filterErr, *want)
+		}
+	}
+}
@@ -2,4 +2,6 @@ module github.com/favxlaw
 
 go 1.22.2
 
-require github.com/mattn/go-sqlite3 v1.14.33 // indirect
+require github.com/mattn/go-sqlite3 v1.14.33
+
+require golang.org/x/image v0.18.0
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// backupInterval is the least time between two snapshots requested over
+// HTTP, so the endpoint can't be used to fill the disk
+const backupInterval = time.Minute
+
+// AdminStore defines the storage operations behind /admin
+type AdminStore interface {
+	Backup(ctx context.Context, dir string, keep int) (models.BackupResult, error)
+}
+
+// AdminHandler handles maintenance endpoints
+type AdminHandler struct {
+	store      AdminStore
+	backupDir  string
+	backupKeep int
+
+	// mu guards lastBackup, the time of the last snapshot this handler
+	// started
+	mu         sync.Mutex
+	lastBackup time.Time
+}
+
+// NewAdminHandler creates a new admin handler that writes snapshots to
+// backupDir and keeps backupKeep days of them
+func NewAdminHandler(s AdminStore, backupDir string, backupKeep int) *AdminHandler {
+	return &AdminHandler{store: s, backupDir: backupDir, backupKeep: backupKeep}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/admin/backup" && r.Method == http.MethodPost:
+		h.backup(w, r)
+	case r.URL.Path == "/admin/backup":
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// backup handles POST /admin/backup by writing a snapshot of the database.
+// Requests within backupInterval of the previous one get a 429.
+func (h *AdminHandler) backup(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	if wait := backupInterval - time.Since(h.lastBackup); wait > 0 {
+		h.mu.Unlock()
+		seconds := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		errorResponse(w, fmt.Sprintf("a backup was just taken; try again in %d seconds", seconds), http.StatusTooManyRequests)
+		return
+	}
+	h.lastBackup = time.Now()
+	h.mu.Unlock()
+
+	result, err := h.store.Backup(r.Context(), h.backupDir, h.backupKeep)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// countingBackups is an AdminStore that counts the snapshots it takes
+type countingBackups struct {
+	count int
+}
+
+func (c *countingBackups) Backup(ctx context.Context, dir string, keep int) (models.BackupResult, error) {
+	c.count++
+	return models.BackupResult{}, nil
+}
+
+func TestBackupIsRateLimited(t *testing.T) {
+	backups := &countingBackups{}
+	h := NewAdminHandler(backups, t.TempDir(), 7)
+
+	if w := serve(h, http.MethodPost, "/admin/backup", "", nil); w.Code != http.StatusCreated {
+		t.Fatalf("first backup status %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	w := serve(h, http.MethodPost, "/admin/backup", "", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("second backup status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if retry := w.Header().Get("Retry-After"); retry != "60" {
+		t.Errorf("Retry-After = %q, want 60", retry)
+	}
+
+	// Once the interval has passed another snapshot may be taken
+	h.lastBackup = time.Now().Add(-backupInterval)
+	if w := serve(h, http.MethodPost, "/admin/backup", "", nil); w.Code != http.StatusCreated {
+		t.Errorf("backup after the interval status %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	if backups.count != 2 {
+		t.Errorf("took %d snapshots, want 2", backups.count)
+	}
+}
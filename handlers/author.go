@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/favxlaw/models"
+)
+
+// AuthorStore defines the storage operations behind /authors
+type AuthorStore interface {
+	ListAuthors(ctx context.Context, q string) ([]models.Author, error)
+	GetAuthor(ctx context.Context, id int) (*models.Author, error)
+	GetAuthorBooks(ctx context.Context, id int) ([]models.Book, error)
+	RenameAuthor(ctx context.Context, id int, name string) (*models.Author, error)
+	MergeAuthors(ctx context.Context, targetID int, sourceIDs []int) (*models.Author, error)
+}
+
+// AuthorHandler handles all author-related HTTP requests
+type AuthorHandler struct {
+	store AuthorStore
+}
+
+// NewAuthorHandler creates a new author handler
+func NewAuthorHandler(s AuthorStore) *AuthorHandler {
+	return &AuthorHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *AuthorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/authors" || r.URL.Path == "/authors/" {
+		if r.Method != http.MethodGet {
+			errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.listAuthors(w, r)
+		return
+	}
+
+	id, rest, err := splitPath(r.URL.Path, "/authors/")
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.getAuthor(w, r, id)
+	case rest == "" && r.Method == http.MethodPut:
+		h.renameAuthor(w, r, id)
+	case rest == "books" && r.Method == http.MethodGet:
+		h.getAuthorBooks(w, r, id)
+	case rest == "merge" && r.Method == http.MethodPost:
+		h.mergeAuthors(w, r, id)
+	case rest == "" || rest == "books" || rest == "merge":
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listAuthors handles GET /authors with an optional ?q= name filter
+func (h *AuthorHandler) listAuthors(w http.ResponseWriter, r *http.Request) {
+	authors, err := h.store.ListAuthors(r.Context(), r.URL.Query().Get("q"))
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(authors)
+}
+
+// getAuthor handles GET /authors/{id}
+func (h *AuthorHandler) getAuthor(w http.ResponseWriter, r *http.Request, id int) {
+	author, err := h.store.GetAuthor(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
+
+// getAuthorBooks handles GET /authors/{id}/books
+func (h *AuthorHandler) getAuthorBooks(w http.ResponseWriter, r *http.Request, id int) {
+	books, err := h.store.GetAuthorBooks(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(books)
+}
+
+// renameAuthor handles PUT /authors/{id}
+func (h *AuthorHandler) renameAuthor(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		Name string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	author, err := h.store.RenameAuthor(r.Context(), id, body.Name)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
+
+// mergeAuthors handles POST /authors/{id}/merge, folding the listed
+// duplicate authors into this one
+func (h *AuthorHandler) mergeAuthors(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		AuthorIDs []int
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if len(body.AuthorIDs) == 0 {
+		errorResponse(w, "AuthorIDs is required", http.StatusBadRequest)
+		return
+	}
+
+	author, err := h.store.MergeAuthors(r.Context(), id, body.AuthorIDs)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
@@ -1,24 +1,66 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/favxlaw/covers"
+	"github.com/favxlaw/filterql"
+	"github.com/favxlaw/isbn"
 	"github.com/favxlaw/models"
 )
 
 // BookStore defines the interface for book storage operations
 type BookStore interface {
-	GetAll() []models.Book
-	GetByID(int) (*models.Book, error)
-	GetByFilters(status, category, sortBy string) []models.Book
-	Create(models.Book) models.Book
-	Update(int, models.Book) error
-	Delete(int) error
+	GetByID(ctx context.Context, id int) (*models.Book, error)
+	GetByFilters(ctx context.Context, filter models.BookFilter) (models.BookPage, error)
+	Create(ctx context.Context, book models.Book) (models.Book, error)
+	Update(ctx context.Context, id int, book models.Book) error
+	Delete(ctx context.Context, id int, version int) error
+	Search(ctx context.Context, q string, limit int) ([]models.SearchResult, error)
+	AddBookTags(ctx context.Context, bookID int, names []string) ([]string, error)
+	RemoveBookTag(ctx context.Context, bookID int, name string) ([]string, error)
+	LogProgress(ctx context.Context, bookID int, entry models.ReadingProgress) (models.ProgressUpdate, error)
+	GetProgress(ctx context.Context, bookID int) ([]models.ReadingProgress, error)
+	StartSession(ctx context.Context, bookID int, reader, note string) (models.ReadingSession, error)
+	StopSession(ctx context.Context, bookID int, reader string, pagesRead int, note string) (models.ReadingSession, error)
+	LogSession(ctx context.Context, bookID int, session models.ReadingSession) (models.ReadingSession, error)
+	GetSessions(ctx context.Context, bookID int) ([]models.ReadingSession, error)
+	GetReadings(ctx context.Context, bookID int) ([]models.Reading, error)
+	UpdateReading(ctx context.Context, bookID int, reading models.Reading) (models.Reading, error)
+	DeleteReading(ctx context.Context, bookID, readingID int) error
+	CreateReview(ctx context.Context, bookID int, review models.Review) (models.Review, error)
+	GetReviews(ctx context.Context, bookID int) ([]models.Review, error)
+	UpdateReview(ctx context.Context, bookID int, review models.Review) (models.Review, error)
+	DeleteReview(ctx context.Context, bookID, reviewID int) error
+	CreateHighlight(ctx context.Context, bookID int, highlight models.Highlight) (models.Highlight, error)
+	GetHighlights(ctx context.Context, bookID int) ([]models.Highlight, error)
+	UpdateHighlight(ctx context.Context, bookID int, highlight models.Highlight) (models.Highlight, error)
+	DeleteHighlight(ctx context.Context, bookID, highlightID int) error
+	LendBook(ctx context.Context, bookID int, loan models.Loan) (models.Loan, error)
+	ReturnBook(ctx context.Context, bookID int, returned time.Time) (models.Loan, error)
+	GetLoans(ctx context.Context, bookID int) ([]models.Loan, error)
+	GetEditions(ctx context.Context, bookID int) ([]models.Book, error)
+	SaveCover(ctx context.Context, bookID int, cover models.Cover, files map[string][]byte) (models.Cover, error)
+	OpenCover(ctx context.Context, bookID int, name string) (*os.File, models.Cover, error)
+	DeleteCover(ctx context.Context, bookID int) error
+}
+
+// legacySorts keeps sort names from before multi-field sorting working
+var legacySorts = map[string]string{
+	"date": "-start_date",
 }
 
 // BookHandler handles all book-related HTTP requests
@@ -33,6 +75,11 @@ func NewBookHandler(s BookStore) *BookHandler {
 
 // ServeHTTP implements http.Handler interface
 func (h *BookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/books/search" {
+		h.handleSearch(w, r)
+		return
+	}
+
 	// Check if path has an ID
 	if r.URL.Path != "/books" && r.URL.Path != "/books/" {
 		h.handleSingleBook(w, r)
@@ -52,17 +99,24 @@ func (h *BookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // handleSingleBook routes single book operations
 func (h *BookHandler) handleSingleBook(w http.ResponseWriter, r *http.Request) {
-	id, err := extractID(r.URL.Path)
+	id, rest, err := splitPath(r.URL.Path, "/books/")
 	if err != nil {
 		errorResponse(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if rest != "" {
+		h.handleBookSubresource(w, r, id, rest)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getBookByID(w, r, id)
 	case http.MethodPut:
 		h.updateBook(w, r, id)
+	case http.MethodPatch:
+		h.patchBook(w, r, id)
 	case http.MethodDelete:
 		h.deleteBook(w, r, id)
 	default:
@@ -70,31 +124,200 @@ func (h *BookHandler) handleSingleBook(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// getAllBooks handles GET /books with optional query parameters
+// handleBookSubresource routes /books/{id}/... paths
+func (h *BookHandler) handleBookSubresource(w http.ResponseWriter, r *http.Request, id int, rest string) {
+	switch {
+	case rest == "tags" && r.Method == http.MethodPost:
+		h.addBookTags(w, r, id)
+	case strings.HasPrefix(rest, "tags/") && r.Method == http.MethodDelete:
+		h.removeBookTag(w, r, id, strings.TrimPrefix(rest, "tags/"))
+	case rest == "progress" && r.Method == http.MethodPost:
+		h.logProgress(w, r, id)
+	case rest == "progress" && r.Method == http.MethodGet:
+		h.getProgress(w, r, id)
+	case rest == "sessions/start" && r.Method == http.MethodPost:
+		h.startSession(w, r, id)
+	case rest == "sessions/stop" && r.Method == http.MethodPost:
+		h.stopSession(w, r, id)
+	case rest == "sessions" && r.Method == http.MethodPost:
+		h.logSession(w, r, id)
+	case rest == "sessions" && r.Method == http.MethodGet:
+		h.getSessions(w, r, id)
+	case rest == "readings" && r.Method == http.MethodGet:
+		h.getReadings(w, r, id)
+	case strings.HasPrefix(rest, "readings/") && r.Method == http.MethodPut:
+		h.updateReading(w, r, id, strings.TrimPrefix(rest, "readings/"))
+	case strings.HasPrefix(rest, "readings/") && r.Method == http.MethodDelete:
+		h.deleteReading(w, r, id, strings.TrimPrefix(rest, "readings/"))
+	case rest == "reviews" && r.Method == http.MethodPost:
+		h.createReview(w, r, id)
+	case rest == "reviews" && r.Method == http.MethodGet:
+		h.getReviews(w, r, id)
+	case strings.HasPrefix(rest, "reviews/") && r.Method == http.MethodPut:
+		h.updateReview(w, r, id, strings.TrimPrefix(rest, "reviews/"))
+	case strings.HasPrefix(rest, "reviews/") && r.Method == http.MethodDelete:
+		h.deleteReview(w, r, id, strings.TrimPrefix(rest, "reviews/"))
+	case rest == "highlights" && r.Method == http.MethodPost:
+		h.createHighlight(w, r, id)
+	case rest == "highlights" && r.Method == http.MethodGet:
+		h.getHighlights(w, r, id)
+	case strings.HasPrefix(rest, "highlights/") && r.Method == http.MethodPut:
+		h.updateHighlight(w, r, id, strings.TrimPrefix(rest, "highlights/"))
+	case strings.HasPrefix(rest, "highlights/") && r.Method == http.MethodDelete:
+		h.deleteHighlight(w, r, id, strings.TrimPrefix(rest, "highlights/"))
+	case rest == "loans" && r.Method == http.MethodPost:
+		h.lendBook(w, r, id)
+	case rest == "loans" && r.Method == http.MethodGet:
+		h.getLoans(w, r, id)
+	case rest == "loans/return" && r.Method == http.MethodPost:
+		h.returnBook(w, r, id)
+	case rest == "editions" && r.Method == http.MethodGet:
+		h.getEditions(w, r, id)
+	case rest == "cover" && r.Method == http.MethodPut:
+		h.putCover(w, r, id)
+	case rest == "cover" && r.Method == http.MethodGet:
+		h.getCover(w, r, id, covers.Original)
+	case rest == "cover" && r.Method == http.MethodDelete:
+		h.deleteCover(w, r, id)
+	case strings.HasPrefix(rest, "cover/") && r.Method == http.MethodGet:
+		h.getCover(w, r, id, strings.TrimPrefix(rest, "cover/"))
+	case rest == "tags" || strings.HasPrefix(rest, "tags/") || rest == "progress" ||
+		rest == "sessions" || strings.HasPrefix(rest, "sessions/") ||
+		rest == "readings" || strings.HasPrefix(rest, "readings/") ||
+		rest == "reviews" || strings.HasPrefix(rest, "reviews/") ||
+		rest == "highlights" || strings.HasPrefix(rest, "highlights/") ||
+		rest == "loans" || rest == "loans/return" || rest == "editions" ||
+		rest == "cover" || strings.HasPrefix(rest, "cover/"):
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// getAllBooks handles GET /books with optional filters and pagination
 func (h *BookHandler) getAllBooks(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
 	query := r.URL.Query()
-	status := query.Get("status")
-	category := query.Get("category")
+	filter, err := parseBookFilter(query)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+	filter.CountTotal = query.Get("count") == "true"
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit = limit
+
+	if token := query.Get("cursor"); token != "" {
+		filter.After, err = decodeCursor(token)
+		if err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.store.GetByFilters(r.Context(), filter)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	setPageHeaders(w, r, page)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Books)
+}
+
+// parseBookFilter reads the filter and sort parameters shared by the
+// endpoints that list books. Pagination is left to the caller.
+func parseBookFilter(query url.Values) (models.BookFilter, error) {
+	filter := models.BookFilter{
+		Status:      query.Get("status"),
+		Category:    query.Get("category"),
+		Tags:        query["tag"],
+		MatchAnyTag: query.Get("tag_mode") == "any",
+	}
+
+	var err error
+	if raw := query.Get("isbn"); raw != "" {
+		filter.ISBN, err = isbn.Normalize(raw)
+		if err != nil {
+			return filter, models.NewValidationError("isbn", "isbn: "+err.Error())
+		}
+	}
+
+	if raw := query.Get("on_loan"); raw != "" {
+		onLoan, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, models.NewValidationError("on_loan", "on_loan must be true or false")
+		}
+		filter.OnLoan = &onLoan
+	}
+
+	if raw := query.Get("min_rating"); raw != "" {
+		minRating, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return filter, models.NewValidationError("min_rating", "min_rating must be a number")
+		}
+		filter.MinRating = &minRating
+	}
+
+	filter.Where, err = filterql.Parse(query.Get("filter"))
+	if err != nil {
+		return filter, err
+	}
+
 	sortBy := query.Get("sort")
+	if alias, ok := legacySorts[sortBy]; ok {
+		sortBy = alias
+	}
+	filter.Sort, err = filterql.ParseSort(sortBy)
+	return filter, err
+}
 
-	// Use database filtering if any filters provided
-	var books []models.Book
-	if status != "" || category != "" || sortBy != "" {
-		books = h.store.GetByFilters(status, category, sortBy)
-	} else {
-		books = h.store.GetAll()
+// handleSearch handles GET /books/search?q= with ranked full-text matches
+func (h *BookHandler) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	q := query.Get("q")
+	if strings.TrimSpace(q) == "" {
+		errorResponse(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.store.Search(r.Context(), q, limit)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(books)
+	json.NewEncoder(w).Encode(results)
 }
 
 // getBookByID handles GET /books/{id}
-func (h *BookHandler) getBookByID(w http.ResponseWriter, _ *http.Request, id int) {
-	book, err := h.store.GetByID(id)
+func (h *BookHandler) getBookByID(w http.ResponseWriter, r *http.Request, id int) {
+	book, err := h.store.GetByID(r.Context(), id)
 	if err != nil {
-		errorResponse(w, "Book not found", http.StatusNotFound)
+		storeErrorResponse(w, err)
+		return
+	}
+
+	setETag(w, book.Version)
+	if header := r.Header.Get("If-None-Match"); header != "" && matchesETag(header, book.Version, true) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -112,9 +335,9 @@ func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = validateBook(newBook)
+	err = models.ValidateBook(&newBook)
 	if err != nil {
-		errorResponse(w, err.Error(), http.StatusBadRequest)
+		storeErrorResponse(w, err)
 		return
 	}
 
@@ -125,19 +348,81 @@ func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create in store
-	created := h.store.Create(newBook)
+	created, err := h.store.Create(r.Context(), newBook)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
 
+	setETag(w, created.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(created)
 }
 
+// getEditions handles GET /books/{id}/editions
+func (h *BookHandler) getEditions(w http.ResponseWriter, r *http.Request, id int) {
+	editions, err := h.store.GetEditions(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(editions)
+}
+
+// addBookTags handles POST /books/{id}/tags
+func (h *BookHandler) addBookTags(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		Tags []string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if len(body.Tags) == 0 {
+		errorResponse(w, "Tags is required", http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateTags(body.Tags); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	tags, err := h.store.AddBookTags(r.Context(), id, body.Tags)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// removeBookTag handles DELETE /books/{id}/tags/{name}
+func (h *BookHandler) removeBookTag(w http.ResponseWriter, r *http.Request, id int, name string) {
+	tags, err := h.store.RemoveBookTag(r.Context(), id, name)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
 // updateBook handles PUT /books/{id}
 func (h *BookHandler) updateBook(w http.ResponseWriter, r *http.Request, id int) {
 	// Get existing book
-	existingBook, err := h.store.GetByID(id)
+	existingBook, err := h.store.GetByID(r.Context(), id)
 	if err != nil {
-		errorResponse(w, "Book not found", http.StatusNotFound)
+		storeErrorResponse(w, err)
+		return
+	}
+
+	if !checkIfMatch(w, r, existingBook.Version) {
 		return
 	}
 
@@ -150,49 +435,133 @@ func (h *BookHandler) updateBook(w http.ResponseWriter, r *http.Request, id int)
 	}
 
 	// Validate
-	err = validateBook(updatedBook)
+	err = models.ValidateBook(&updatedBook)
 	if err != nil {
-		errorResponse(w, err.Error(), http.StatusBadRequest)
+		storeErrorResponse(w, err)
 		return
 	}
 
 	// Preserve certain fields
 	updatedBook.StartDate = existingBook.StartDate
+	updatedBook.Version = existingBook.Version
+	models.ApplyAuthorEdit(&updatedBook, existingBook)
+	models.ApplyEndDate(&updatedBook, existingBook)
+
+	h.saveBook(w, r, id, updatedBook)
+}
 
-	// Handle EndDate logic
-	if (updatedBook.Status == models.StatusFinished || updatedBook.Status == models.StatusAbandoned) &&
-		existingBook.EndDate == nil {
-		now := time.Now()
-		updatedBook.EndDate = &now
-	} else if updatedBook.Status == models.StatusReading || updatedBook.Status == models.StatusToRead {
-		updatedBook.EndDate = nil
-	} else {
-		updatedBook.EndDate = existingBook.EndDate
+// patchBook handles PATCH /books/{id} with either a JSON Merge Patch
+// (RFC 7396) or a JSON Patch (RFC 6902), selected by Content-Type
+func (h *BookHandler) patchBook(w http.ResponseWriter, r *http.Request, id int) {
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mediaType(r.Header.Get("Content-Type")) {
+	case mergePatchContentType:
+		apply = applyMergePatch
+	case jsonPatchContentType:
+		apply = applyJSONPatch
+	default:
+		errorResponse(w, "Content-Type must be "+mergePatchContentType+" or "+jsonPatchContentType,
+			http.StatusUnsupportedMediaType)
+		return
 	}
 
+	existingBook, err := h.store.GetByID(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	if !checkIfMatch(w, r, existingBook.Version) {
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		errorResponse(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := json.Marshal(existingBook)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	patched, err := apply(doc, patch)
+	if err != nil {
+		errorResponse(w, "Invalid patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Decode the merged document strictly so typos in field names are reported
+	var updatedBook models.Book
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&updatedBook); err != nil {
+		errorResponse(w, "Invalid patched book: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = models.ValidateBook(&updatedBook)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	// Same rules as PUT: identity and StartDate are not client-controlled,
+	// and editing only the display string re-derives the credits
+	models.ApplyAuthorEdit(&updatedBook, existingBook)
+	updatedBook.ID = existingBook.ID
+	updatedBook.StartDate = existingBook.StartDate
+	updatedBook.Version = existingBook.Version
+	models.ApplyEndDate(&updatedBook, existingBook)
+
+	h.saveBook(w, r, id, updatedBook)
+}
+
+// saveBook writes an updated book and responds with the stored state.
+// updatedBook.Version must hold the version the change was based on, so a
+// concurrent write between our read and this update is rejected.
+func (h *BookHandler) saveBook(w http.ResponseWriter, r *http.Request, id int, updatedBook models.Book) {
 	// Update in store
-	err = h.store.Update(id, updatedBook)
+	err := h.store.Update(r.Context(), id, updatedBook)
 	if err != nil {
-		errorResponse(w, err.Error(), http.StatusInternalServerError)
+		storeErrorResponse(w, err)
 		return
 	}
 
 	// Fetch the updated book from database to return the exact stored state
-	book, err := h.store.GetByID(id)
+	book, err := h.store.GetByID(r.Context(), id)
 	if err != nil {
-		errorResponse(w, "Failed to fetch updated book", http.StatusInternalServerError)
+		storeErrorResponse(w, err)
 		return
 	}
 
+	setETag(w, book.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(book)
 }
 
 // deleteBook handles DELETE /books/{id}
 func (h *BookHandler) deleteBook(w http.ResponseWriter, r *http.Request, id int) {
-	err := h.store.Delete(id)
+	// Without If-Match the delete is unconditional (version 0)
+	version := 0
+	if r.Header.Get("If-Match") != "" {
+		existingBook, err := h.store.GetByID(r.Context(), id)
+		if err != nil {
+			storeErrorResponse(w, err)
+			return
+		}
+
+		if !checkIfMatch(w, r, existingBook.Version) {
+			return
+		}
+		version = existingBook.Version
+	}
+
+	err := h.store.Delete(r.Context(), id, version)
 	if err != nil {
-		errorResponse(w, "Book not found", http.StatusNotFound)
+		storeErrorResponse(w, err)
 		return
 	}
 
@@ -201,53 +570,46 @@ func (h *BookHandler) deleteBook(w http.ResponseWriter, r *http.Request, id int)
 
 // Helper functions
 
-// extractID extracts the book ID from the URL path
-func extractID(path string) (int, error) {
-	idStr := strings.TrimPrefix(path, "/books/")
-	if idStr == "" || idStr == path {
-		return 0, fmt.Errorf("no ID provided")
-	}
-
-	id, err := strconv.Atoi(idStr)
+// mediaType returns the media type of a Content-Type header without parameters
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		return 0, fmt.Errorf("invalid ID format")
+		return ""
 	}
-
-	return id, nil
+	return mediaType
 }
 
-// validateBook validates book data
-func validateBook(book models.Book) error {
-	if book.Title == "" {
-		return fmt.Errorf("title is required")
+// splitPath parses "/prefix/{id}/rest" into the ID and the remaining
+// path, which is empty for the resource itself
+func splitPath(path, prefix string) (int, string, error) {
+	idStr := strings.TrimPrefix(path, prefix)
+	if idStr == "" || idStr == path {
+		return 0, "", fmt.Errorf("no ID provided")
 	}
 
-	if book.Author == "" {
-		return fmt.Errorf("author is required")
+	rest := ""
+	if slash := strings.IndexByte(idStr, '/'); slash >= 0 {
+		idStr, rest = idStr[:slash], strings.Trim(idStr[slash+1:], "/")
 	}
 
-	if book.Status != "" {
-		validStatuses := []models.BookStatus{
-			models.StatusToRead,
-			models.StatusReading,
-			models.StatusFinished,
-			models.StatusAbandoned,
-		}
-
-		isValid := false
-		for _, validStatus := range validStatuses {
-			if book.Status == validStatus {
-				isValid = true
-				break
-			}
-		}
-
-		if !isValid {
-			return fmt.Errorf("status must be one of: to_read, reading, finished, abandoned")
-		}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid ID format")
 	}
 
-	return nil
+	return id, rest, nil
+}
+
+// filterErrorResponse sends a 400 that points at the offending token
+func filterErrorResponse(w http.ResponseWriter, err *filterql.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":    err.Error(),
+		"param":    err.Param,
+		"position": err.Pos,
+		"token":    err.Token,
+	})
 }
 
 // errorResponse sends a JSON error response
@@ -258,3 +620,31 @@ func errorResponse(w http.ResponseWriter, message string, statusCode int) {
 		"error": message,
 	})
 }
+
+// storeErrorResponse maps a domain error onto an HTTP status code.
+// Anything unrecognised is logged and reported as a 500 without details.
+func storeErrorResponse(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	var filterErr *filterql.Error
+
+	switch {
+	case errors.As(err, &validationErr):
+		errorResponse(w, validationErr.Message, http.StatusBadRequest)
+	case errors.As(err, &filterErr):
+		filterErrorResponse(w, filterErr)
+	case errors.Is(err, models.ErrNotFound):
+		errorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrConflict):
+		errorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, models.ErrPreconditionFailed):
+		errorResponse(w, "Book was modified by another request", http.StatusPreconditionFailed)
+	case errors.Is(err, models.ErrUnavailable),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		log.Printf("store unavailable: %v", err)
+		errorResponse(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+	default:
+		log.Printf("store error: %v", err)
+		errorResponse(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
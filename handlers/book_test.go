@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// TestUpdateAuthorRederivesCredits checks that editing only Author in a
+// book read back from the API replaces its credits, for PUT and PATCH
+func TestUpdateAuthorRederivesCredits(t *testing.T) {
+	s := newTestStore(t)
+	h := NewBookHandler(s)
+
+	book, err := s.Create(context.Background(), models.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: models.StatusToRead, StartDate: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	target := "/books/" + strconv.Itoa(book.ID)
+
+	updates := []struct {
+		method      string
+		author      string
+		contentType string
+	}{
+		{http.MethodPut, "Brian Herbert", "application/json"},
+		{http.MethodPatch, "Kevin J. Anderson", mergePatchContentType},
+	}
+
+	for _, update := range updates {
+		w := serve(h, http.MethodGet, target, "", nil)
+		var current models.Book
+		if err := json.NewDecoder(w.Body).Decode(&current); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+
+		// Send the whole book back with only the display string edited
+		current.Author = update.author
+		body, _ := json.Marshal(current)
+
+		w = serve(h, update.method, target, string(body), http.Header{"Content-Type": {update.contentType}})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s status %d: %s", update.method, w.Code, w.Body)
+		}
+
+		var updated models.Book
+		if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if updated.Author != update.author || len(updated.Authors) != 1 || updated.Authors[0].Name != update.author {
+			t.Errorf("%s: Author %q with Authors %+v, want both %q", update.method, updated.Author, updated.Authors, update.author)
+		}
+	}
+}
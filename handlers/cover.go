@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/favxlaw/covers"
+	"github.com/favxlaw/models"
+)
+
+// maxCoverSize caps the size of an uploaded cover image in bytes
+const maxCoverSize = 10 << 20
+
+// putCover handles PUT /books/{id}/cover. The body is the image itself,
+// with a Content-Type of image/jpeg, image/png or image/webp.
+func (h *BookHandler) putCover(w http.ResponseWriter, r *http.Request, id int) {
+	contentType := mediaType(r.Header.Get("Content-Type"))
+	if _, ok := covers.ContentTypes[contentType]; !ok {
+		errorResponse(w, "Content-Type must be image/jpeg, image/png or image/webp", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCoverSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			errorResponse(w, fmt.Sprintf("cover must be at most %d MB", maxCoverSize>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		errorResponse(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(data) == 0 {
+		errorResponse(w, "cover image is required", http.StatusBadRequest)
+		return
+	}
+
+	set, err := covers.Process(data, contentType)
+	if errors.Is(err, covers.ErrUnsupported) {
+		errorResponse(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		storeErrorResponse(w, models.NewValidationError("Cover", err.Error()))
+		return
+	}
+
+	cover, err := h.store.SaveCover(r.Context(), id, models.Cover{
+		ContentType: set.ContentType,
+		Width:       set.Width,
+		Height:      set.Height,
+		Size:        int64(len(data)),
+	}, set.Files)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cover)
+}
+
+// getCover handles GET /books/{id}/cover and GET /books/{id}/cover/{size}.
+// Clients revalidate covers on every use; the ETag is the hash stored
+// with the cover plus the size, so it changes exactly when the image does
+// and an unchanged cover costs a 304.
+func (h *BookHandler) getCover(w http.ResponseWriter, r *http.Request, id int, size string) {
+	if _, ok := covers.Sizes[size]; !ok && size != covers.Original {
+		errorResponse(w, "cover size must be one of: small, medium, large, original", http.StatusNotFound)
+		return
+	}
+
+	file, cover, err := h.store.OpenCover(r.Context(), id, size)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+	defer file.Close()
+
+	contentType := "image/jpeg"
+	if size == covers.Original {
+		contentType = cover.ContentType
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", `"`+cover.Hash+"-"+size+`"`)
+	// No Last-Modified: it only has second resolution, and a cover
+	// replaced within the same second would look unchanged
+	http.ServeContent(w, r, "", time.Time{}, file)
+}
+
+// deleteCover handles DELETE /books/{id}/cover
+func (h *BookHandler) deleteCover(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.store.DeleteCover(r.Context(), id); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
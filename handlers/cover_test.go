@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/favxlaw/models"
+)
+
+// pngCover encodes a small single-colour PNG
+func pngCover(t *testing.T, c color.Color) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 60))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 60; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode error: %v", err)
+	}
+	return buf.String()
+}
+
+// TestCoverETag checks that covers are revalidated against the hash
+// stored on upload: each size has its own tag, an unchanged cover is a
+// 304 and a new upload changes every tag
+func TestCoverETag(t *testing.T) {
+	s := newTestStore(t)
+	h := NewBookHandler(s)
+
+	book, err := s.Create(context.Background(), models.Book{Title: "Dune", Author: "Frank Herbert", Status: models.StatusToRead})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	target := "/books/" + strconv.Itoa(book.ID) + "/cover"
+	pngType := http.Header{"Content-Type": {"image/png"}}
+
+	upload := func(c color.Color) {
+		t.Helper()
+		if w := serve(h, http.MethodPut, target, pngCover(t, c), pngType); w.Code != http.StatusOK {
+			t.Fatalf("PUT cover: status %d: %s", w.Code, w.Body)
+		}
+	}
+	tags := func() map[string]string {
+		t.Helper()
+		tags := map[string]string{}
+		for _, size := range []string{"", "/small", "/large"} {
+			w := serve(h, http.MethodGet, target+size, "", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET cover%s: status %d: %s", size, w.Code, w.Body)
+			}
+			tags[size] = w.Header().Get("ETag")
+			if tags[size] == "" {
+				t.Fatalf("GET cover%s has no ETag", size)
+			}
+		}
+		return tags
+	}
+
+	upload(color.RGBA{R: 200, A: 255})
+	first := tags()
+	if first[""] == first["/small"] || first["/small"] == first["/large"] {
+		t.Errorf("sizes share an ETag: %v", first)
+	}
+
+	w := serve(h, http.MethodGet, target+"/small", "", http.Header{"If-None-Match": {first["/small"]}})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("GET unchanged cover: status %d, want %d", w.Code, http.StatusNotModified)
+	}
+
+	upload(color.RGBA{B: 200, A: 255})
+	for size, tag := range tags() {
+		if tag == first[size] {
+			t.Errorf("cover%s kept its ETag %s after a new upload", size, tag)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag formats a book version as a strong entity tag
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag writes the ETag header for a book version
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// matchesETag reports whether a comma-separated If-Match/If-None-Match
+// header value matches the version. If-Match uses the strong comparison
+// of RFC 9110, so weak tags never match; If-None-Match uses the weak
+// one, which ignores the W/ prefix.
+func matchesETag(header string, version int, weak bool) bool {
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces an If-Match header against the stored version.
+// Requests without the header are allowed through for older clients.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || matchesETag(header, version, false) {
+		return true
+	}
+
+	errorResponse(w, "Book was modified by another request", http.StatusPreconditionFailed)
+	return false
+}
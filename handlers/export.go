@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/favxlaw/models"
+)
+
+// exportBatchSize is how many books are loaded per query while an export
+// streams, so memory use doesn't grow with the library
+const exportBatchSize = 200
+
+// ExportStore defines the storage operations behind /export
+type ExportStore interface {
+	GetByFilters(ctx context.Context, filter models.BookFilter) (models.BookPage, error)
+}
+
+// ExportHandler handles GET /export
+type ExportHandler struct {
+	store ExportStore
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(s ExportStore) *ExportHandler {
+	return &ExportHandler{store: s}
+}
+
+// exportWriter writes books in one export format. flush pushes what has
+// been written so far to the underlying writer.
+type exportWriter interface {
+	writeHeader() error
+	writeBook(book *models.Book) error
+	flush() error
+}
+
+// exportFormat describes a format of GET /export
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer) exportWriter
+}
+
+// exportFormats lists the formats of GET /export by ?format= name
+var exportFormats = map[string]exportFormat{
+	"csv":    {"text/csv; charset=utf-8", "csv", newCSVExport},
+	"jsonl":  {"application/x-ndjson", "jsonl", newJSONLExport},
+	"md":     {"text/markdown; charset=utf-8", "md", newMarkdownExport},
+	"bibtex": {"application/x-bibtex; charset=utf-8", "bib", newBibTeXExport},
+}
+
+// ServeHTTP handles GET /export?format=csv|jsonl|md|bibtex. It takes the
+// same filter and sort parameters as GET /books and streams every
+// matching book, loading them a batch at a time. CSV and JSON Lines use
+// the csvColumns, so POST /import/csv and /import/jsonl read them back.
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/export" {
+		errorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		errorResponse(w, "format must be csv, jsonl, md or bibtex", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseBookFilter(query)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+	filter.Limit = exportBatchSize
+
+	// The first batch is loaded before anything is sent, so a bad filter
+	// still gets a proper error response
+	page, err := h.store.GetByFilters(r.Context(), filter)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="library-%s.%s"`,
+		time.Now().Format("2006-01-02"), format.extension))
+
+	out := format.newWriter(w)
+	flusher, _ := w.(http.Flusher)
+	if err := out.writeHeader(); err != nil {
+		log.Printf("export failed: %v", err)
+		return
+	}
+
+	for {
+		for i := range page.Books {
+			if err := out.writeBook(&page.Books[i]); err != nil {
+				log.Printf("export failed: %v", err)
+				return
+			}
+		}
+		if err := out.flush(); err != nil {
+			log.Printf("export failed: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if page.Next == nil {
+			return
+		}
+		filter.After = page.Next
+		page, err = h.store.GetByFilters(r.Context(), filter)
+		if err != nil {
+			// The status line is gone; all that is left is cutting the
+			// response short
+			log.Printf("export failed: %v", err)
+			return
+		}
+	}
+}
+
+// exportColumns are the csvColumns written by CSV and JSON Lines exports
+var exportColumns = formattedColumns()
+
+// formattedColumns returns the csvColumns that have a format
+func formattedColumns() []csvColumn {
+	var columns []csvColumn
+	for _, column := range csvColumns {
+		if column.format != nil {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// csvExport writes the CSV import format
+type csvExport struct {
+	w *csv.Writer
+}
+
+func newCSVExport(w io.Writer) exportWriter {
+	return &csvExport{w: csv.NewWriter(w)}
+}
+
+func (e *csvExport) writeHeader() error {
+	var header []string
+	for _, column := range exportColumns {
+		header = append(header, column.name)
+	}
+	return e.w.Write(header)
+}
+
+func (e *csvExport) writeBook(book *models.Book) error {
+	var record []string
+	for _, column := range exportColumns {
+		record = append(record, csvCell(column.format(book)))
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExport) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonlExport writes one JSON object per book, keyed by the exportColumns
+// names in their order
+type jsonlExport struct {
+	w *bufio.Writer
+}
+
+func newJSONLExport(w io.Writer) exportWriter {
+	return &jsonlExport{w: bufio.NewWriter(w)}
+}
+
+func (e *jsonlExport) writeHeader() error { return nil }
+
+func (e *jsonlExport) writeBook(book *models.Book) error {
+	e.w.WriteByte('{')
+	for i, column := range exportColumns {
+		value, err := json.Marshal(column.format(book))
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", column.name, err)
+		}
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		e.w.WriteString(strconv.Quote(column.name))
+		e.w.WriteByte(':')
+		e.w.Write(value)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *jsonlExport) flush() error { return e.w.Flush() }
+
+// markdownExport writes a table meant for reading rather than importing
+type markdownExport struct {
+	w *bufio.Writer
+}
+
+func newMarkdownExport(w io.Writer) exportWriter {
+	return &markdownExport{w: bufio.NewWriter(w)}
+}
+
+func (e *markdownExport) writeHeader() error {
+	_, err := e.w.WriteString("# Library\n\n" +
+		"| Title | Author | Status | Started | Finished | Rating | Tags |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- |\n")
+	return err
+}
+
+func (e *markdownExport) writeBook(book *models.Book) error {
+	finished := ""
+	if book.EndDate != nil {
+		finished = book.EndDate.Format("2006-01-02")
+	}
+	rating := ""
+	if book.Rating != nil {
+		rating = strconv.FormatFloat(*book.Rating, 'f', -1, 64) + "/5"
+	}
+
+	cells := []string{
+		book.Title,
+		book.Author,
+		string(book.Status),
+		book.StartDate.Format("2006-01-02"),
+		finished,
+		rating,
+		strings.Join(book.Tags, ", "),
+	}
+	for i, cell := range cells {
+		cells[i] = markdownCell(cell)
+	}
+	_, err := e.w.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	return err
+}
+
+func (e *markdownExport) flush() error { return e.w.Flush() }
+
+// markdownCell keeps text from breaking out of a table cell
+var markdownCell = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace
+
+// bibtexExport writes one @book entry per book
+type bibtexExport struct {
+	w *bufio.Writer
+}
+
+func newBibTeXExport(w io.Writer) exportWriter {
+	return &bibtexExport{w: bufio.NewWriter(w)}
+}
+
+func (e *bibtexExport) writeHeader() error { return nil }
+
+func (e *bibtexExport) writeBook(book *models.Book) error {
+	var authors []string
+	for _, credit := range book.Authors {
+		if credit.Role == models.RoleAuthor {
+			authors = append(authors, credit.Name)
+		}
+	}
+	if len(authors) == 0 && book.Author != "" {
+		authors = []string{book.Author}
+	}
+
+	year := ""
+	if book.PublicationYear != 0 {
+		year = strconv.Itoa(book.PublicationYear)
+	}
+
+	fmt.Fprintf(e.w, "@book{%s,\n", bibtexKey(book, authors, year))
+	fields := [][2]string{
+		{"title", book.Title},
+		{"author", strings.Join(authors, " and ")},
+		{"publisher", book.Publisher},
+		{"year", year},
+		{"isbn", book.ISBN},
+		{"language", book.Language},
+	}
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(e.w, "  %s = {%s},\n", field[0], bibtexEscape(field[1]))
+		}
+	}
+	_, err := e.w.WriteString("}\n\n")
+	return err
+}
+
+func (e *bibtexExport) flush() error { return e.w.Flush() }
+
+// bibtexEscape escapes the characters TeX treats specially
+var bibtexEscape = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`,
+	"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+).Replace
+
+// bibtexKey builds a citation key such as herbert1965-2 from the first
+// author's surname and the year. The book ID keeps keys unique.
+func bibtexKey(book *models.Book, authors []string, year string) string {
+	surname := ""
+	if len(authors) > 0 {
+		name := authors[0]
+		if i := strings.Index(name, ","); i >= 0 {
+			// "Martin, Robert C."
+			name = name[:i]
+		} else if fields := strings.Fields(name); len(fields) > 0 {
+			name = fields[len(fields)-1]
+		}
+		surname = strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, name)
+	}
+	if surname == "" {
+		surname = "book"
+	}
+	return fmt.Sprintf("%s%s-%d", surname, year, book.ID)
+}
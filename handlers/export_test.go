@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/filterql"
+	"github.com/favxlaw/models"
+	"github.com/favxlaw/store"
+)
+
+// TestExportRoundTrip exports a library as CSV and as JSON Lines, imports
+// each file into an empty library and checks that every field survives
+func TestExportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := newTestStore(t)
+
+	series, err := source.CreateSeries(ctx, models.Series{Name: "Dune Chronicles"})
+	if err != nil {
+		t.Fatalf("CreateSeries error: %v", err)
+	}
+	first, second := 1.0, 1.5
+	rating := 4.5
+	finished := time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	dune, err := source.Create(ctx, models.Book{
+		Title:     "Dune",
+		Author:    "Frank Herbert",
+		Status:    models.StatusFinished,
+		Category:  "  Science Fiction ",
+		Notes:     "Quote:\n\n    indented code block\n",
+		StartDate: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		EndDate:   &finished,
+		PageCount: 412,
+		ISBN:      "9780441013593", Publisher: "Ace", PublicationYear: 1965, Language: "en",
+		Format:   models.FormatPaperback,
+		SeriesID: &series.ID, SeriesPosition: &first,
+		Rating: &rating,
+		Tags:   []string{"classic", "desert"},
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	_, err = source.Create(ctx, models.Book{
+		Title:  "Der Wüstenplanet",
+		Author: "Frank Herbert",
+		Authors: []models.BookAuthor{
+			{Name: "Frank Herbert", Role: models.RoleAuthor},
+			{Name: "Jakob Schmidt", Role: models.RoleTranslator},
+		},
+		Status:    models.StatusToRead,
+		Notes:     "\tLeading tab, \"quotes\", commas; and ;semicolons\n",
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Language:  "de",
+		Format:    models.FormatEbook,
+		WorkID:    dune.ID,
+		SeriesID:  &series.ID, SeriesPosition: &second,
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	_, err = source.Create(ctx, models.Book{
+		Title: "Untitled Notes", Author: "Anonymous", Status: models.StatusReading,
+		StartDate: time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	want, wantWorks := roundTripBooks(t, source)
+
+	formats := []struct {
+		format      string
+		path        string
+		contentType string
+	}{
+		{"csv", "/import/csv", "text/csv"},
+		{"jsonl", "/import/jsonl", "application/x-ndjson"},
+	}
+
+	for _, f := range formats {
+		t.Run(f.format, func(t *testing.T) {
+			export := serve(NewExportHandler(source), http.MethodGet, "/export?format="+f.format, "", nil)
+			if export.Code != http.StatusOK {
+				t.Fatalf("export status %d: %s", export.Code, export.Body)
+			}
+
+			target := newTestStore(t)
+			imported := serve(NewImportHandler(target), http.MethodPost, f.path, export.Body.String(),
+				http.Header{"Content-Type": {f.contentType}})
+			if imported.Code != http.StatusOK {
+				t.Fatalf("import status %d: %s", imported.Code, imported.Body)
+			}
+
+			got, gotWorks := roundTripBooks(t, target)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("imported books differ\n got %+v\nwant %+v", got, want)
+			}
+			if !reflect.DeepEqual(gotWorks, wantWorks) {
+				t.Errorf("imported works = %q, want %q", gotWorks, wantWorks)
+			}
+		})
+	}
+}
+
+// roundTripBooks lists a library's books by title with the fields that
+// only make sense within one library zeroed, and for each book the titles
+// of the editions in its work
+func roundTripBooks(t *testing.T, s *store.SQLiteStore) ([]models.Book, []string) {
+	t.Helper()
+	page, err := s.GetByFilters(context.Background(), models.BookFilter{
+		Sort: []filterql.SortField{{Field: "title"}},
+	})
+	if err != nil {
+		t.Fatalf("GetByFilters error: %v", err)
+	}
+
+	editions := map[int][]string{}
+	for _, book := range page.Books {
+		editions[book.WorkID] = append(editions[book.WorkID], book.Title)
+	}
+
+	books := page.Books
+	works := make([]string, len(books))
+	for i := range books {
+		b := &books[i]
+		works[i] = strings.Join(editions[b.WorkID], ", ")
+		b.ID, b.WorkID, b.Version, b.SeriesID = 0, 0, 0, nil
+		b.StartDate = b.StartDate.UTC()
+		if b.EndDate != nil {
+			end := b.EndDate.UTC()
+			b.EndDate = &end
+		}
+		for j := range b.Authors {
+			b.Authors[j].AuthorID = 0
+		}
+		b.Readings = nil
+	}
+	return books, works
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/importer"
+	"github.com/favxlaw/models"
+)
+
+// goodreadsStatuses maps Goodreads exclusive shelves onto statuses.
+// Custom exclusive shelves become to_read and are kept as a tag.
+var goodreadsStatuses = map[string]models.BookStatus{
+	"read":              models.StatusFinished,
+	"currently-reading": models.StatusReading,
+	"to-read":           models.StatusToRead,
+	"did-not-finish":    models.StatusAbandoned,
+	"dnf":               models.StatusAbandoned,
+	"abandoned":         models.StatusAbandoned,
+}
+
+// goodreadsFormats maps Goodreads bindings onto formats
+var goodreadsFormats = map[string]models.BookFormat{
+	"hardcover":             models.FormatHardcover,
+	"paperback":             models.FormatPaperback,
+	"mass market paperback": models.FormatPaperback,
+	"trade paperback":       models.FormatPaperback,
+	"kindle edition":        models.FormatEbook,
+	"ebook":                 models.FormatEbook,
+	"nook":                  models.FormatEbook,
+	"audiobook":             models.FormatAudiobook,
+	"audible audio":         models.FormatAudiobook,
+	"audio cd":              models.FormatAudiobook,
+}
+
+// goodreadsRecord reads the cells of one row by Goodreads column name
+type goodreadsRecord struct {
+	index  map[string]int
+	record []string
+}
+
+// get returns a cell with the ="..." wrapper Goodreads puts around ISBNs
+// removed; missing columns read as empty
+func (g goodreadsRecord) get(column string) string {
+	i, ok := g.index[column]
+	if !ok || i >= len(g.record) {
+		return ""
+	}
+	value := strings.TrimSpace(g.record[i])
+	if strings.HasPrefix(value, `="`) && strings.HasSuffix(value, `"`) {
+		value = value[2 : len(value)-1]
+	}
+	return strings.TrimSpace(value)
+}
+
+// importGoodreads handles POST /import/goodreads with a Goodreads library
+// export. Books are matched by ISBN, then by title and author, so the
+// same export can be imported again without creating duplicates. Shelves
+// other than the exclusive one become tags, or with ?shelves=category the
+// first of them becomes the category and the rest tags.
+func (h *ImportHandler) importGoodreads(w http.ResponseWriter, r *http.Request) {
+	if mediaType(r.Header.Get("Content-Type")) != "text/csv" {
+		errorResponse(w, "Content-Type must be text/csv", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	shelvesAs := r.URL.Query().Get("shelves")
+	if shelvesAs != "" && shelvesAs != "tags" && shelvesAs != "category" {
+		errorResponse(w, "shelves must be tags or category", http.StatusBadRequest)
+		return
+	}
+
+	reader := newCSVReader(http.MaxBytesReader(w, r.Body, maxImportSize))
+	header, err := readCSVHeader(reader)
+	if err != nil {
+		csvErrorResponse(w, err)
+		return
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, required := range []string{"Title", "Author", "Exclusive Shelf"} {
+		if _, ok := index[required]; !ok {
+			errorResponse(w, fmt.Sprintf("missing column %q; is this a Goodreads export?", required), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var pending []importer.Row
+	var report models.ImportReport
+	for {
+		record, line, err := readCSVRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			report.Add(models.ImportResult{Line: line, Action: models.ImportRejected,
+				Error: fmt.Sprintf("expected %d fields, got %d", len(header), len(record))})
+			continue
+		}
+		if err != nil {
+			csvErrorResponse(w, err)
+			return
+		}
+
+		row := goodreadsRecord{index: index, record: record}
+		isbn := row.get("ISBN13")
+		if isbn == "" {
+			isbn = row.get("ISBN")
+		}
+
+		pending = append(pending, importer.Row{
+			Line:        line,
+			ISBN:        isbn,
+			Title:       row.get("Title"),
+			Author:      row.get("Author"),
+			MatchTitle:  true,
+			SetsEndDate: true,
+			Apply: func(book *models.Book) error {
+				return applyGoodreadsRow(book, row, isbn, shelvesAs == "category")
+			},
+		})
+	}
+
+	report, err = importer.Run(r.Context(), h.store, pending, report, dryRun)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+	importResponse(w, report)
+}
+
+// applyGoodreadsRow copies a Goodreads row onto a book. Tags are added to
+// the ones the book already has, and an unrated row keeps the rating.
+func applyGoodreadsRow(book *models.Book, row goodreadsRecord, isbn string, shelfCategory bool) error {
+	book.Title = row.get("Title")
+	book.Author = row.get("Author")
+	book.ISBN = isbn
+
+	exclusive := row.get("Exclusive Shelf")
+	status, ok := goodreadsStatuses[exclusive]
+	if !ok {
+		status = models.StatusToRead
+	}
+	book.Status = status
+
+	var shelves []string
+	for _, shelf := range strings.Split(row.get("Bookshelves"), ",") {
+		shelf = strings.TrimSpace(shelf)
+		if shelf != "" && shelf != exclusive {
+			shelves = append(shelves, shelf)
+		}
+	}
+	if !ok && exclusive != "" {
+		shelves = append(shelves, exclusive)
+	}
+	if shelfCategory && len(shelves) > 0 {
+		book.Category, shelves = shelves[0], shelves[1:]
+	}
+	book.Tags = append(append([]string{}, book.Tags...), shelves...)
+
+	if raw := row.get("My Rating"); raw != "" && raw != "0" {
+		rating, err := parseCSVFloat("My Rating", raw)
+		if err != nil {
+			return err
+		}
+		book.Rating = rating
+	}
+
+	if format, ok := goodreadsFormats[strings.ToLower(row.get("Binding"))]; ok {
+		book.Format = format
+	}
+	if err := parseCSVInt(&book.PageCount, "Number of Pages", row.get("Number of Pages")); err != nil {
+		return err
+	}
+	if book.Format == models.FormatAudiobook {
+		// Goodreads lists audiobook lengths as page counts
+		book.PageCount = 0
+	}
+
+	if publisher := row.get("Publisher"); publisher != "" {
+		book.Publisher = publisher
+	}
+	if err := parseCSVInt(&book.PublicationYear, "Year Published", row.get("Year Published")); err != nil {
+		return err
+	}
+
+	added, err := parseGoodreadsDate("Date Added", row.get("Date Added"))
+	if err != nil {
+		return err
+	}
+	read, err := parseGoodreadsDate("Date Read", row.get("Date Read"))
+	if err != nil {
+		return err
+	}
+
+	// Only finished books keep the date they were read
+	book.EndDate = nil
+	if status == models.StatusFinished {
+		book.EndDate = read
+	}
+	if added != nil {
+		book.StartDate = *added
+	}
+	if book.EndDate != nil && (book.StartDate.IsZero() || book.EndDate.Before(book.StartDate)) {
+		book.StartDate = *book.EndDate
+	}
+
+	return nil
+}
+
+// parseGoodreadsDate parses an optional Goodreads date such as 2024/03/01
+func parseGoodreadsDate(column, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{"2006/01/02", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, models.NewValidationError(column, column+" must be a date like 2024/03/01")
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// maxHighlightLength caps the text of a single highlight
+const maxHighlightLength = 10000
+
+// HighlightStore defines the storage operations behind /highlights
+type HighlightStore interface {
+	SearchHighlights(ctx context.Context, q string, limit int) ([]models.HighlightResult, error)
+	DailyHighlight(ctx context.Context, day time.Time) (models.HighlightResult, error)
+}
+
+// HighlightHandler handles requests across the highlights of all books
+type HighlightHandler struct {
+	store HighlightStore
+}
+
+// NewHighlightHandler creates a new highlight handler
+func NewHighlightHandler(s HighlightStore) *HighlightHandler {
+	return &HighlightHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *HighlightHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/highlights/search":
+		h.searchHighlights(w, r)
+	case "/highlights/daily":
+		h.dailyHighlight(w, r)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// searchHighlights handles GET /highlights/search?q= with ranked matches
+func (h *HighlightHandler) searchHighlights(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := query.Get("q")
+	if strings.TrimSpace(q) == "" {
+		errorResponse(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.store.SearchHighlights(r.Context(), q, limit)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// dailyHighlight handles GET /highlights/daily, optionally for ?date=2025-01-31
+func (h *HighlightHandler) dailyHighlight(w http.ResponseWriter, r *http.Request) {
+	day := time.Now()
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			errorResponse(w, "date must look like 2025-01-31", http.StatusBadRequest)
+			return
+		}
+		day = parsed
+	}
+
+	result, err := h.store.DailyHighlight(r.Context(), day)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// createHighlight handles POST /books/{id}/highlights
+func (h *BookHandler) createHighlight(w http.ResponseWriter, r *http.Request, id int) {
+	var highlight models.Highlight
+	if err := json.NewDecoder(r.Body).Decode(&highlight); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if highlight.Color == "" {
+		highlight.Color = models.ColorYellow
+	}
+
+	if err := validateHighlight(highlight); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	created, err := h.store.CreateHighlight(r.Context(), id, highlight)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// getHighlights handles GET /books/{id}/highlights
+func (h *BookHandler) getHighlights(w http.ResponseWriter, r *http.Request, id int) {
+	highlights, err := h.store.GetHighlights(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(highlights)
+}
+
+// updateHighlight handles PUT /books/{id}/highlights/{highlightID}
+func (h *BookHandler) updateHighlight(w http.ResponseWriter, r *http.Request, id int, highlightID string) {
+	hid, err := strconv.Atoi(highlightID)
+	if err != nil {
+		errorResponse(w, "Invalid highlight ID", http.StatusBadRequest)
+		return
+	}
+
+	var highlight models.Highlight
+	if err := json.NewDecoder(r.Body).Decode(&highlight); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	highlight.ID = hid
+
+	if highlight.Color == "" {
+		highlight.Color = models.ColorYellow
+	}
+
+	if err := validateHighlight(highlight); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	updated, err := h.store.UpdateHighlight(r.Context(), id, highlight)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// deleteHighlight handles DELETE /books/{id}/highlights/{highlightID}
+func (h *BookHandler) deleteHighlight(w http.ResponseWriter, r *http.Request, id int, highlightID string) {
+	hid, err := strconv.Atoi(highlightID)
+	if err != nil {
+		errorResponse(w, "Invalid highlight ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.DeleteHighlight(r.Context(), id, hid); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateHighlight validates a highlight before it is saved
+func validateHighlight(highlight models.Highlight) error {
+	if strings.TrimSpace(highlight.Text) == "" {
+		return models.NewValidationError("Text", "highlight text is required")
+	}
+
+	if len(highlight.Text) > maxHighlightLength {
+		return models.NewValidationError("Text", "highlight text is too long")
+	}
+
+	if highlight.Page != nil && *highlight.Page < 0 {
+		return models.NewValidationError("Page", "page cannot be negative")
+	}
+
+	switch highlight.Color {
+	case models.ColorYellow, models.ColorGreen, models.ColorBlue, models.ColorPink, models.ColorPurple:
+	default:
+		return models.NewValidationError("Color", "color must be one of: yellow, green, blue, pink, purple")
+	}
+
+	return nil
+}
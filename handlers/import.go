@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/importer"
+	"github.com/favxlaw/models"
+)
+
+// maxImportSize caps the size of an uploaded import file in bytes
+const maxImportSize = 10 << 20
+
+// ImportStore defines the storage operations behind /import
+type ImportStore interface {
+	GetByID(ctx context.Context, id int) (*models.Book, error)
+	MatchBook(ctx context.Context, isbn, title, author string) (*models.Book, error)
+	ImportBooks(ctx context.Context, rows []models.ImportRow, commit bool) ([]models.ImportResult, error)
+}
+
+// ImportHandler handles bulk imports of books
+type ImportHandler struct {
+	store ImportStore
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(s ImportStore) *ImportHandler {
+	return &ImportHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *ImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var handle http.HandlerFunc
+	switch r.URL.Path {
+	case "/import/csv":
+		handle = h.importCSV
+	case "/import/jsonl":
+		handle = h.importJSONL
+	case "/import/goodreads":
+		handle = h.importGoodreads
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	handle(w, r)
+}
+
+// csvColumn is a column of the CSV import and export format. Names match
+// the fields of the filter language where there is one. format returns
+// the column's value for export: a string, int, float64, []string or nil
+// for an empty cell. Columns without a format are only read on import.
+type csvColumn struct {
+	name   string
+	parse  func(book *models.Book, value string) error
+	format func(book *models.Book) interface{}
+}
+
+// csvColumns lists the columns POST /import/csv understands and
+// GET /export writes
+var csvColumns = []csvColumn{
+	{"id",
+		func(b *models.Book, v string) error { return parseCSVInt(&b.ID, "id", v) },
+		func(b *models.Book) interface{} { return b.ID }},
+	{"title",
+		func(b *models.Book, v string) error { b.Title = v; return nil },
+		func(b *models.Book) interface{} { return b.Title }},
+	{"author",
+		func(b *models.Book, v string) error { b.Author = v; return nil },
+		func(b *models.Book) interface{} { return b.Author }},
+	{"credits",
+		func(b *models.Book, v string) error { b.Authors = parseCredits(v); return nil },
+		func(b *models.Book) interface{} { return formatCredits(b.Authors) }},
+	{"status",
+		func(b *models.Book, v string) error { b.Status = models.BookStatus(v); return nil },
+		func(b *models.Book) interface{} { return string(b.Status) }},
+	{"category",
+		func(b *models.Book, v string) error { b.Category = v; return nil },
+		func(b *models.Book) interface{} { return b.Category }},
+	{"notes",
+		func(b *models.Book, v string) error { b.Notes = v; return nil },
+		func(b *models.Book) interface{} { return b.Notes }},
+	{"tags",
+		func(b *models.Book, v string) error { b.Tags = splitCSVList(v); return nil },
+		func(b *models.Book) interface{} { return append([]string{}, b.Tags...) }},
+	{"start_date",
+		func(b *models.Book, v string) error {
+			if v == "" {
+				return nil
+			}
+			t, err := parseCSVDate("start_date", v)
+			if err != nil {
+				return err
+			}
+			b.StartDate = *t
+			return nil
+		},
+		func(b *models.Book) interface{} { return b.StartDate.Format(time.RFC3339) }},
+	{"end_date",
+		func(b *models.Book, v string) error {
+			t, err := parseCSVDate("end_date", v)
+			b.EndDate = t
+			return err
+		},
+		func(b *models.Book) interface{} {
+			if b.EndDate == nil {
+				return nil
+			}
+			return b.EndDate.Format(time.RFC3339)
+		}},
+	{"page_count",
+		func(b *models.Book, v string) error { return parseCSVInt(&b.PageCount, "page_count", v) },
+		func(b *models.Book) interface{} { return b.PageCount }},
+	{"rating",
+		func(b *models.Book, v string) error {
+			rating, err := parseCSVFloat("rating", v)
+			b.Rating = rating
+			return err
+		},
+		func(b *models.Book) interface{} { return optionalFloat(b.Rating) }},
+	{"isbn",
+		func(b *models.Book, v string) error { b.ISBN = v; return nil },
+		func(b *models.Book) interface{} { return b.ISBN }},
+	{"publisher",
+		func(b *models.Book, v string) error { b.Publisher = v; return nil },
+		func(b *models.Book) interface{} { return b.Publisher }},
+	{"publication_year",
+		func(b *models.Book, v string) error {
+			return parseCSVInt(&b.PublicationYear, "publication_year", v)
+		},
+		func(b *models.Book) interface{} { return b.PublicationYear }},
+	{"language",
+		func(b *models.Book, v string) error { b.Language = v; return nil },
+		func(b *models.Book) interface{} { return b.Language }},
+	{"format",
+		func(b *models.Book, v string) error { b.Format = models.BookFormat(v); return nil },
+		func(b *models.Book) interface{} { return string(b.Format) }},
+	{"duration_minutes",
+		func(b *models.Book, v string) error {
+			return parseCSVInt(&b.DurationMinutes, "duration_minutes", v)
+		},
+		func(b *models.Book) interface{} { return b.DurationMinutes }},
+	{"work_id",
+		func(b *models.Book, v string) error { return parseCSVInt(&b.WorkID, "work_id", v) },
+		func(b *models.Book) interface{} { return b.WorkID }},
+	// series_id is kept for files exported before the series column; IDs
+	// mean nothing in another library, so exports name the series instead
+	{"series_id",
+		func(b *models.Book, v string) error {
+			b.SeriesID = nil
+			if v == "" {
+				return nil
+			}
+			var id int
+			if err := parseCSVInt(&id, "series_id", v); err != nil {
+				return err
+			}
+			b.SeriesID = &id
+			return nil
+		},
+		nil},
+	// A named series is resolved by the store; see columnRow
+	{"series",
+		func(b *models.Book, v string) error {
+			if v == "" {
+				b.SeriesID = nil
+			}
+			return nil
+		},
+		func(b *models.Book) interface{} { return b.SeriesName }},
+	{"series_position",
+		func(b *models.Book, v string) error {
+			position, err := parseCSVFloat("series_position", v)
+			b.SeriesPosition = position
+			return err
+		},
+		func(b *models.Book) interface{} { return optionalFloat(b.SeriesPosition) }},
+}
+
+// textColumns hold free text, which is imported verbatim so exports
+// round-trip without loss. Cells of the other columns are trimmed.
+var textColumns = map[string]bool{
+	"title":     true,
+	"author":    true,
+	"category":  true,
+	"notes":     true,
+	"publisher": true,
+}
+
+// cellValue is the value of a cell as the column's parse function gets it
+func cellValue(column, cell string) string {
+	if textColumns[column] {
+		return cell
+	}
+	return strings.TrimSpace(cell)
+}
+
+// optionalFloat is the export value of an optional number
+func optionalFloat(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+// csvCell renders an export value as a CSV cell that parses back to it
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ";")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// importCSV handles POST /import/csv. The body is a CSV file whose header
+// names the columns; see csvColumns. Rows with an id update that book,
+// rows with the ISBN of a book in the library update it, and the rest
+// create new books. With ?dry_run=true nothing is saved.
+func (h *ImportHandler) importCSV(w http.ResponseWriter, r *http.Request) {
+	if mediaType(r.Header.Get("Content-Type")) != "text/csv" {
+		errorResponse(w, "Content-Type must be text/csv", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reader := newCSVReader(http.MaxBytesReader(w, r.Body, maxImportSize))
+	header, err := readCSVHeader(reader)
+	if err != nil {
+		csvErrorResponse(w, err)
+		return
+	}
+
+	columns := make([]csvColumn, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		column, ok := findCSVColumn(name)
+		if !ok {
+			errorResponse(w, fmt.Sprintf("unknown column %q", name), http.StatusBadRequest)
+			return
+		}
+		if seen[column.name] {
+			errorResponse(w, fmt.Sprintf("duplicate column %q", name), http.StatusBadRequest)
+			return
+		}
+		seen[column.name] = true
+		columns[i] = column
+	}
+
+	var pending []importer.Row
+	var report models.ImportReport
+	for {
+		record, line, err := readCSVRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			report.Add(models.ImportResult{Line: line, Action: models.ImportRejected,
+				Error: fmt.Sprintf("expected %d fields, got %d", len(columns), len(record))})
+			continue
+		}
+		if err != nil {
+			csvErrorResponse(w, err)
+			return
+		}
+
+		values := map[string]string{}
+		for i, column := range columns {
+			values[column.name] = cellValue(column.name, record[i])
+		}
+		row, err := columnRow(line, columns, values)
+		if err != nil {
+			report.Add(models.ImportResult{Line: line, Action: models.ImportRejected,
+				Title: values["title"], Error: importer.ErrorMessage(err)})
+			continue
+		}
+		pending = append(pending, row)
+	}
+
+	report, err = importer.Run(r.Context(), h.store, pending, report, dryRun)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+	importResponse(w, report)
+}
+
+// columnRow builds the import row for a record of csvColumns values.
+// Only the given columns are applied, so missing ones keep the values of
+// the book being updated.
+func columnRow(line int, columns []csvColumn, values map[string]string) (importer.Row, error) {
+	_, setsEndDate := values["end_date"]
+	_, setsWorkID := values["work_id"]
+	row := importer.Row{
+		Line:        line,
+		ISBN:        values["isbn"],
+		Title:       values["title"],
+		Author:      values["author"],
+		SetsEndDate: setsEndDate,
+		SetsWorkID:  setsWorkID,
+		SeriesName:  values["series"],
+	}
+	if err := parseCSVInt(&row.ID, "id", values["id"]); err != nil {
+		return row, err
+	}
+
+	row.Apply = func(book *models.Book) error {
+		for _, column := range columns {
+			if column.name == "id" {
+				continue
+			}
+			if err := column.parse(book, values[column.name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return row, nil
+}
+
+// importResponse sends an import report. A report with rejected rows is a
+// 422, since nothing was saved.
+func importResponse(w http.ResponseWriter, report models.ImportReport) {
+	status := http.StatusOK
+	if report.Rejected > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// parseDryRun reads the dry_run query parameter
+func parseDryRun(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("dry_run")
+	if raw == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("dry_run must be true or false")
+	}
+	return dryRun, nil
+}
+
+// newCSVReader reads CSV leniently: rows may have any number of fields,
+// so a short row is reported on its own instead of failing the file
+func newCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// readCSVHeader reads the header row, dropping the byte order mark that
+// spreadsheet programs put in front of UTF-8 files
+func readCSVHeader(reader *csv.Reader) ([]string, error) {
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, models.NewValidationError("CSV", "CSV file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	reader.FieldsPerRecord = len(header)
+	return header, nil
+}
+
+// readCSVRecord reads the next row and the line it starts on. Rows with
+// the wrong number of fields are returned with csv.ErrFieldCount.
+func readCSVRecord(reader *csv.Reader) ([]string, int, error) {
+	record, err := reader.Read()
+	if len(record) == 0 {
+		return record, 0, err
+	}
+	line, _ := reader.FieldPos(0)
+	return record, line, err
+}
+
+// csvErrorResponse reports a file that could not be read as CSV
+func csvErrorResponse(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &tooLarge):
+		errorResponse(w, fmt.Sprintf("import file must be at most %d MB", maxImportSize>>20), http.StatusRequestEntityTooLarge)
+	case errors.As(err, &parseErr):
+		errorResponse(w, fmt.Sprintf("line %d: %v", parseErr.Line, parseErr.Err), http.StatusBadRequest)
+	default:
+		storeErrorResponse(w, err)
+	}
+}
+
+// findCSVColumn looks a header name up in csvColumns, ignoring case and
+// treating spaces like underscores
+func findCSVColumn(name string) (csvColumn, bool) {
+	key := strings.ReplaceAll(strings.ToLower(name), " ", "_")
+	for _, column := range csvColumns {
+		if column.name == key {
+			return column, true
+		}
+	}
+	return csvColumn{}, false
+}
+
+// splitCSVList splits a ;-separated cell such as a tag list
+func splitCSVList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// formatCredits renders credits for the credits column: one name per
+// credit, with the role in parentheses unless it is author
+func formatCredits(credits []models.BookAuthor) []string {
+	names := make([]string, len(credits))
+	for i, credit := range credits {
+		names[i] = credit.Name
+		if credit.Role != "" && credit.Role != models.RoleAuthor {
+			names[i] += " (" + string(credit.Role) + ")"
+		}
+	}
+	return names
+}
+
+// parseCredits reads the credits column. The store resolves the names to
+// authors; empty means credits are derived from the author column.
+func parseCredits(value string) []models.BookAuthor {
+	var credits []models.BookAuthor
+	for _, name := range splitCSVList(value) {
+		role := models.RoleAuthor
+		if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+			switch suffix := models.AuthorRole(name[i+2 : len(name)-1]); suffix {
+			case models.RoleAuthor, models.RoleTranslator, models.RoleEditor:
+				name, role = strings.TrimSpace(name[:i]), suffix
+			}
+		}
+		credits = append(credits, models.BookAuthor{Name: name, Role: role})
+	}
+	return credits
+}
+
+// parseCSVInt parses an optional whole number; empty means 0
+func parseCSVInt(dst *int, column, value string) error {
+	if value == "" {
+		*dst = 0
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return models.NewValidationError(column, column+" must be a whole number")
+	}
+	*dst = n
+	return nil
+}
+
+// parseCSVFloat parses an optional number; empty means unset
+func parseCSVFloat(column, value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, models.NewValidationError(column, column+" must be a number")
+	}
+	return &f, nil
+}
+
+// parseCSVDate parses an optional date, either 2006-01-02 or RFC 3339
+func parseCSVDate(column, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, models.NewValidationError(column, column+" must be a date like 2025-01-31")
+	}
+	return &t, nil
+}
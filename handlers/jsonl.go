@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/favxlaw/importer"
+	"github.com/favxlaw/models"
+)
+
+// jsonlContentTypes are the media types accepted for JSON Lines
+var jsonlContentTypes = map[string]bool{
+	"application/x-ndjson": true,
+	"application/jsonl":    true,
+}
+
+// importJSONL handles POST /import/jsonl. Each line is a JSON object
+// keyed by the csvColumns names, as written by GET /export?format=jsonl,
+// and is imported exactly like a CSV row with those columns. Keys may
+// differ from line to line; a missing key keeps the book's value.
+func (h *ImportHandler) importJSONL(w http.ResponseWriter, r *http.Request) {
+	if !jsonlContentTypes[mediaType(r.Header.Get("Content-Type"))] {
+		errorResponse(w, "Content-Type must be application/x-ndjson", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scanner := bufio.NewScanner(http.MaxBytesReader(w, r.Body, maxImportSize))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportSize)
+
+	var pending []importer.Row
+	var report models.ImportReport
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if line == 1 {
+			text = bytes.TrimPrefix(text, []byte("\ufeff"))
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		var row importer.Row
+		columns, values, err := jsonlValues(text)
+		if err == nil {
+			row, err = columnRow(line, columns, values)
+		}
+		if err != nil {
+			report.Add(models.ImportResult{Line: line, Action: models.ImportRejected,
+				Title: values["title"], Error: importer.ErrorMessage(err)})
+			continue
+		}
+		pending = append(pending, row)
+	}
+	if err := scanner.Err(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, bufio.ErrTooLong) {
+			errorResponse(w, fmt.Sprintf("import file must be at most %d MB", maxImportSize>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		storeErrorResponse(w, err)
+		return
+	}
+	if line == 0 {
+		errorResponse(w, "JSON Lines file is empty", http.StatusBadRequest)
+		return
+	}
+
+	report, err = importer.Run(r.Context(), h.store, pending, report, dryRun)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+	importResponse(w, report)
+}
+
+// jsonlValues turns one JSON object into CSV cell values so the columns
+// parse it the same way as a CSV row
+func jsonlValues(text []byte) ([]csvColumn, map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, nil, models.NewValidationError("JSON", "line is not a JSON object: "+err.Error())
+	}
+
+	var columns []csvColumn
+	values := make(map[string]string, len(object))
+	for _, column := range csvColumns {
+		value, ok := object[column.name]
+		if !ok {
+			continue
+		}
+		cell, err := jsonlCell(column.name, value)
+		if err != nil {
+			return nil, values, err
+		}
+		columns = append(columns, column)
+		values[column.name] = cellValue(column.name, cell)
+	}
+
+	for key := range object {
+		if _, ok := values[key]; !ok {
+			return nil, values, models.NewValidationError(key, fmt.Sprintf("unknown key %q", key))
+		}
+	}
+	return columns, values, nil
+}
+
+// jsonlCell renders a decoded JSON value as a CSV cell: null is empty,
+// numbers keep their text and lists are ;-separated
+func jsonlCell(name string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return "", models.NewValidationError(name, name+" must be a list of strings")
+			}
+			if strings.Contains(s, ";") {
+				return "", models.NewValidationError(name, name+" cannot contain ;")
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ";"), nil
+	default:
+		return "", models.NewValidationError(name, name+" must be a string, number or null")
+	}
+}
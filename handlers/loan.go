@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// LoanStore defines the storage operations behind /loans
+type LoanStore interface {
+	ListLoans(ctx context.Context, overdueOn *time.Time) ([]models.LoanListing, error)
+}
+
+// LoanHandler handles requests across the loans of all books
+type LoanHandler struct {
+	store LoanStore
+}
+
+// NewLoanHandler creates a new loan handler
+func NewLoanHandler(s LoanStore) *LoanHandler {
+	return &LoanHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *LoanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/loans" && r.URL.Path != "/loans/" {
+		errorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.listLoans(w, r)
+}
+
+// listLoans handles GET /loans, the books lent out right now. With
+// ?overdue=true only loans past their due date are listed.
+func (h *LoanHandler) listLoans(w http.ResponseWriter, r *http.Request) {
+	var overdueOn *time.Time
+	if r.URL.Query().Get("overdue") == "true" {
+		today := time.Now()
+		overdueOn = &today
+	}
+
+	loans, err := h.store.ListLoans(r.Context(), overdueOn)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loans)
+}
+
+// lendBook handles POST /books/{id}/loans
+func (h *BookHandler) lendBook(w http.ResponseWriter, r *http.Request, id int) {
+	var loan models.Loan
+	if err := json.NewDecoder(r.Body).Decode(&loan); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	loan.Borrower = strings.TrimSpace(loan.Borrower)
+	loan.Contact = strings.TrimSpace(loan.Contact)
+
+	if err := validateLoan(loan); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	created, err := h.store.LendBook(r.Context(), id, loan)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// returnBook handles POST /books/{id}/loans/return. The body may set
+// ReturnedDate; it defaults to now.
+func (h *BookHandler) returnBook(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		ReturnedDate *time.Time
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	returned := time.Now().UTC().Truncate(time.Second)
+	if body.ReturnedDate != nil {
+		if body.ReturnedDate.After(time.Now()) {
+			storeErrorResponse(w, models.NewValidationError("ReturnedDate", "returned date cannot be in the future"))
+			return
+		}
+		returned = *body.ReturnedDate
+	}
+
+	loan, err := h.store.ReturnBook(r.Context(), id, returned)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loan)
+}
+
+// getLoans handles GET /books/{id}/loans
+func (h *BookHandler) getLoans(w http.ResponseWriter, r *http.Request, id int) {
+	loans, err := h.store.GetLoans(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loans)
+}
+
+// validateLoan validates a loan before the book is lent
+func validateLoan(loan models.Loan) error {
+	if loan.Borrower == "" {
+		return models.NewValidationError("Borrower", "borrower is required")
+	}
+
+	if !loan.LentDate.IsZero() && loan.LentDate.After(time.Now()) {
+		return models.NewValidationError("LentDate", "lent date cannot be in the future")
+	}
+
+	if loan.DueDate != nil && !loan.LentDate.IsZero() && loan.DueDate.Before(loan.LentDate) {
+		return models.NewValidationError("DueDate", "due date cannot be before the lent date")
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/favxlaw/models"
+)
+
+// Page size limits for list endpoints
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// cursorPayload is the JSON form of a cursor before base64 encoding.
+// Clients must treat the encoded string as opaque.
+type cursorPayload struct {
+	SortBy string   `json:"s,omitempty"`
+	Values []string `json:"v"`
+}
+
+// encodeCursor turns a store cursor into an opaque URL-safe token
+func encodeCursor(cursor models.Cursor) string {
+	data, _ := json.Marshal(cursorPayload{
+		SortBy: cursor.SortBy,
+		Values: cursor.Values,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a token produced by encodeCursor
+func decodeCursor(token string) (*models.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &models.Cursor{
+		SortBy: payload.SortBy,
+		Values: payload.Values,
+	}, nil
+}
+
+// parseLimit reads the limit query parameter, applying the default and cap
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("limit must be a positive number")
+	}
+
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return limit, nil
+}
+
+// setPageHeaders adds the Link header for the next page and the total count
+func setPageHeaders(w http.ResponseWriter, r *http.Request, page models.BookPage) {
+	if page.Next != nil {
+		query := r.URL.Query()
+		query.Set("cursor", encodeCursor(*page.Next))
+
+		next := *r.URL
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
+	if page.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.Itoa(*page.Total))
+	}
+}
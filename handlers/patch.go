@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Content types accepted by PATCH requests
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// patchOperation is a single RFC 6902 operation
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	// hasValue records whether the operation has a value member at all,
+	// since null is a value like any other
+	hasValue bool
+}
+
+// UnmarshalJSON decodes an operation and notes whether it has a value
+func (op *patchOperation) UnmarshalJSON(data []byte) error {
+	type plainOperation patchOperation
+	if err := json.Unmarshal(data, (*plainOperation)(op)); err != nil {
+		return err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	_, op.hasValue = members["value"]
+	return nil
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to a JSON document
+func applyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target, patchValue interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	return json.Marshal(mergeValue(target, patchValue))
+}
+
+// mergeValue implements the MergePatch algorithm from RFC 7396 section 2
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+// applyJSONPatch applies an RFC 6902 JSON Patch to a JSON document.
+// Operations are applied in order and the whole patch fails if any one does.
+func applyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %w", err)
+	}
+
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		var err error
+		target, err = applyOperation(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// applyOperation applies one JSON Patch operation and returns the new document
+func applyOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if !op.hasValue {
+			return nil, fmt.Errorf("value is required")
+		}
+		var value interface{}
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, fmt.Errorf("invalid value: %w", err)
+			}
+		}
+
+		switch op.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			if len(path) == 0 {
+				return value, nil
+			}
+			if _, err := getValue(doc, path); err != nil {
+				return nil, err
+			}
+			if doc, err = removeValue(doc, path); err != nil {
+				return nil, err
+			}
+			return addValue(doc, path, value)
+		default:
+			current, err := getValue(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !jsonEqual(current, value) {
+				return nil, fmt.Errorf("test failed")
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return removeValue(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("cannot move a value into one of its children")
+			}
+			if doc, err = removeValue(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return addValue(doc, path, value)
+
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens, nil
+}
+
+// getValue returns the value the path points at
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	}
+	return current, nil
+}
+
+// addValue inserts value at path, replacing object members and shifting array elements
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		index := len(node)
+		if last != "-" {
+			if index, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		updated := append(node[:index:index], append([]interface{}{value}, node[index:]...)...)
+		return setValue(doc, path[:len(path)-1], updated)
+	default:
+		return nil, fmt.Errorf("path not found")
+	}
+}
+
+// removeValue deletes the value at path
+func removeValue(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok {
+			return nil, fmt.Errorf("path not found")
+		}
+		delete(node, last)
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated := append(node[:index:index], node[index+1:]...)
+		return setValue(doc, path[:len(path)-1], updated)
+	default:
+		return nil, fmt.Errorf("path not found")
+	}
+}
+
+// setValue overwrites the value at an existing path. Arrays are replaced
+// rather than mutated because growing them can reallocate.
+func setValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+	default:
+		return nil, fmt.Errorf("path not found")
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array reference token and checks it against max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return index, nil
+}
+
+// isPrefix reports whether prefix is a leading part of path
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonEqual compares two decoded JSON values structurally
+func jsonEqual(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// deepCopy duplicates a decoded JSON value so copies don't share maps or slices
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
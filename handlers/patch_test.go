@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	// The examples from RFC 7396 appendix A
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		got, err := applyMergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("applyMergePatch(%s, %s) error: %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("applyMergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyMergePatchInvalid(t *testing.T) {
+	if _, err := applyMergePatch([]byte(`{}`), []byte(`{"a":`)); err == nil {
+		t.Error("applyMergePatch accepted a malformed patch")
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add null", `{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+		{"add replaces member", `{"a":1}`, `[{"op":"add","path":"/a","value":[1]}]`, `{"a":[1]}`},
+		{"add array element", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"add array end", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{"add whole document", `{"a":1}`, `[{"op":"add","path":"","value":{"b":2}}]`, `{"b":2}`},
+		{"replace", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`},
+		{"replace with null", `{"a":1,"b":2}`, `[{"op":"replace","path":"/a","value":null}]`, `{"a":null,"b":2}`},
+		{"replace array element", `[1,2,3]`, `[{"op":"replace","path":"/1","value":9}]`, `[1,9,3]`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"remove array element", `[1,2,3]`, `[{"op":"remove","path":"/0"}]`, `[2,3]`},
+		{"test then replace", `{"a":1}`, `[{"op":"test","path":"/a","value":1},{"op":"replace","path":"/a","value":2}]`, `{"a":2}`},
+		{"test null", `{"a":null}`, `[{"op":"test","path":"/a","value":null}]`, `{"a":null}`},
+		{"move", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`},
+		{"move array element", `[1,2,3]`, `[{"op":"move","from":"/0","path":"/-"}]`, `[2,3,1]`},
+		{"copy", `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":[1]},"c":{"b":[1]}}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+		{"empty patch", `{"a":1}`, `[]`, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("applyJSONPatch error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("applyJSONPatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add without value", `{}`, `[{"op":"add","path":"/a"}]`, "value is required"},
+		{"replace without value", `{"a":1}`, `[{"op":"replace","path":"/a"}]`, "value is required"},
+		{"test without value", `{"a":1}`, `[{"op":"test","path":"/a"}]`, "value is required"},
+		{"replace missing member", `{}`, `[{"op":"replace","path":"/a","value":1}]`, "path not found"},
+		{"remove missing member", `{}`, `[{"op":"remove","path":"/a"}]`, "path not found"},
+		{"remove whole document", `{}`, `[{"op":"remove","path":""}]`, "cannot remove the whole document"},
+		{"add to missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, "path not found"},
+		{"index out of range", `[1]`, `[{"op":"add","path":"/2","value":1}]`, "out of range"},
+		{"leading zero index", `[1,2]`, `[{"op":"remove","path":"/01"}]`, "invalid array index"},
+		{"test mismatch", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`, "test failed"},
+		{"test null against value", `{"a":1}`, `[{"op":"test","path":"/a","value":null}]`, "test failed"},
+		{"move into child", `{"a":{}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`, "into one of its children"},
+		{"bad pointer", `{}`, `[{"op":"add","path":"a","value":1}]`, "invalid JSON pointer"},
+		{"unknown operation", `{}`, `[{"op":"merge","path":"/a"}]`, `unsupported operation "merge"`},
+		{"not a list", `{}`, `{"op":"add","path":"/a","value":1}`, "invalid JSON patch"},
+		{"reports operation", `{"a":1}`, `[{"op":"test","path":"/a","value":1},{"op":"remove","path":"/b"}]`, "operation 1 (remove /b)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if err == nil {
+				t.Fatalf("applyJSONPatch = %s, want error containing %q", got, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("applyJSONPatch error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchIsAtomic(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	patch := []byte(`[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":3}]`)
+
+	if _, err := applyJSONPatch(doc, patch); err == nil {
+		t.Fatal("applyJSONPatch succeeded although an operation failed")
+	}
+	if string(doc) != `{"a":1}` {
+		t.Errorf("document changed to %s", doc)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/favxlaw/models"
+)
+
+// logProgress handles POST /books/{id}/progress
+func (h *BookHandler) logProgress(w http.ResponseWriter, r *http.Request, id int) {
+	var entry models.ReadingProgress
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateProgress(entry); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	update, err := h.store.LogProgress(r.Context(), id, entry)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	setETag(w, update.Book.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(update)
+}
+
+// getProgress handles GET /books/{id}/progress
+func (h *BookHandler) getProgress(w http.ResponseWriter, r *http.Request, id int) {
+	history, err := h.store.GetProgress(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// validateProgress validates a progress entry before it is logged
+func validateProgress(entry models.ReadingProgress) error {
+	if entry.Page == nil && entry.Percent == nil && strings.TrimSpace(entry.Location) == "" {
+		return models.NewValidationError("Page", "one of Page, Percent or Location is required")
+	}
+
+	if entry.Page != nil && *entry.Page < 0 {
+		return models.NewValidationError("Page", "page cannot be negative")
+	}
+
+	if entry.Percent != nil && (*entry.Percent < 0 || *entry.Percent > 100) {
+		return models.NewValidationError("Percent", "percent must be between 0 and 100")
+	}
+
+	return nil
+}
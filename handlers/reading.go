@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/favxlaw/models"
+)
+
+// getReadings handles GET /books/{id}/readings
+func (h *BookHandler) getReadings(w http.ResponseWriter, r *http.Request, id int) {
+	readings, err := h.store.GetReadings(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(readings)
+}
+
+// updateReading handles PUT /books/{id}/readings/{readingID}
+func (h *BookHandler) updateReading(w http.ResponseWriter, r *http.Request, id int, readingID string) {
+	rid, err := strconv.Atoi(readingID)
+	if err != nil {
+		errorResponse(w, "Invalid reading ID", http.StatusBadRequest)
+		return
+	}
+
+	var reading models.Reading
+	if err := json.NewDecoder(r.Body).Decode(&reading); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	reading.ID = rid
+
+	if err := validateReading(reading); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	updated, err := h.store.UpdateReading(r.Context(), id, reading)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// deleteReading handles DELETE /books/{id}/readings/{readingID}
+func (h *BookHandler) deleteReading(w http.ResponseWriter, r *http.Request, id int, readingID string) {
+	rid, err := strconv.Atoi(readingID)
+	if err != nil {
+		errorResponse(w, "Invalid reading ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.DeleteReading(r.Context(), id, rid); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateReading validates a read-through before it is saved
+func validateReading(reading models.Reading) error {
+	if reading.StartDate.IsZero() {
+		return models.NewValidationError("StartDate", "StartDate is required")
+	}
+
+	switch reading.Outcome {
+	case "":
+		if reading.EndDate != nil {
+			return models.NewValidationError("Outcome", "a read-through with an EndDate needs an Outcome")
+		}
+	case models.OutcomeFinished, models.OutcomeAbandoned:
+		if reading.EndDate == nil {
+			return models.NewValidationError("EndDate", "a read-through with an Outcome needs an EndDate")
+		}
+	default:
+		return models.NewValidationError("Outcome", "outcome must be one of: finished, abandoned")
+	}
+
+	if reading.EndDate != nil && reading.EndDate.Before(reading.StartDate) {
+		return models.NewValidationError("EndDate", "EndDate cannot be before StartDate")
+	}
+
+	return models.ValidateRating("Rating", reading.Rating)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/favxlaw/markdown"
+	"github.com/favxlaw/models"
+)
+
+// maxReviewLength caps the Markdown body of a review
+const maxReviewLength = 100000
+
+// renderedReview is a review with its body rendered as sanitized HTML
+type renderedReview struct {
+	models.Review
+	HTML string
+}
+
+// createReview handles POST /books/{id}/reviews
+func (h *BookHandler) createReview(w http.ResponseWriter, r *http.Request, id int) {
+	var review models.Review
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateReview(review); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	created, err := h.store.CreateReview(r.Context(), id, review)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reviewResponse(r, created))
+}
+
+// getReviews handles GET /books/{id}/reviews. With ?format=html each
+// review also carries its body rendered as HTML.
+func (h *BookHandler) getReviews(w http.ResponseWriter, r *http.Request, id int) {
+	reviews, err := h.store.GetReviews(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	response := make([]interface{}, len(reviews))
+	for i, review := range reviews {
+		response[i] = reviewResponse(r, review)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// updateReview handles PUT /books/{id}/reviews/{reviewID}
+func (h *BookHandler) updateReview(w http.ResponseWriter, r *http.Request, id int, reviewID string) {
+	rid, err := strconv.Atoi(reviewID)
+	if err != nil {
+		errorResponse(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	var review models.Review
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	review.ID = rid
+
+	if err := validateReview(review); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	updated, err := h.store.UpdateReview(r.Context(), id, review)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviewResponse(r, updated))
+}
+
+// deleteReview handles DELETE /books/{id}/reviews/{reviewID}
+func (h *BookHandler) deleteReview(w http.ResponseWriter, r *http.Request, id int, reviewID string) {
+	rid, err := strconv.Atoi(reviewID)
+	if err != nil {
+		errorResponse(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.DeleteReview(r.Context(), id, rid); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reviewResponse adds the rendered HTML to a review when the request asks for it
+func reviewResponse(r *http.Request, review models.Review) interface{} {
+	if r.URL.Query().Get("format") != "html" {
+		return review
+	}
+	return renderedReview{Review: review, HTML: markdown.ToHTML(review.Body)}
+}
+
+// validateReview validates a review before it is saved
+func validateReview(review models.Review) error {
+	if strings.TrimSpace(review.Body) == "" {
+		return models.NewValidationError("Body", "review body is required")
+	}
+
+	if len(review.Body) > maxReviewLength {
+		return models.NewValidationError("Body", "review body is too long")
+	}
+
+	return nil
+}
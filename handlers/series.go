@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/favxlaw/filterql"
+	"github.com/favxlaw/models"
+)
+
+// maxSeriesNameLength caps the length of a series name
+const maxSeriesNameLength = 200
+
+// SeriesStore defines the storage operations behind /series
+type SeriesStore interface {
+	ListSeries(ctx context.Context) ([]models.Series, error)
+	GetSeries(ctx context.Context, id int) (*models.SeriesDetail, error)
+	CreateSeries(ctx context.Context, series models.Series) (models.Series, error)
+	UpdateSeries(ctx context.Context, series models.Series) (*models.Series, error)
+	DeleteSeries(ctx context.Context, id int) error
+	GetByFilters(ctx context.Context, filter models.BookFilter) (models.BookPage, error)
+}
+
+// SeriesHandler handles all series-related HTTP requests
+type SeriesHandler struct {
+	store SeriesStore
+}
+
+// NewSeriesHandler creates a new series handler
+func NewSeriesHandler(s SeriesStore) *SeriesHandler {
+	return &SeriesHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *SeriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/series" || r.URL.Path == "/series/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.listSeries(w, r)
+		case http.MethodPost:
+			h.createSeries(w, r)
+		default:
+			errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, rest, err := splitPath(r.URL.Path, "/series/")
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.getSeries(w, r, id)
+	case rest == "" && r.Method == http.MethodPut:
+		h.updateSeries(w, r, id)
+	case rest == "" && r.Method == http.MethodDelete:
+		h.deleteSeries(w, r, id)
+	case rest == "next" && r.Method == http.MethodGet:
+		h.nextUnread(w, r, id)
+	case rest == "" || rest == "next":
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listSeries handles GET /series
+func (h *SeriesHandler) listSeries(w http.ResponseWriter, r *http.Request) {
+	list, err := h.store.ListSeries(r.Context())
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// getSeries handles GET /series/{id}, the series with its books in
+// reading order
+func (h *SeriesHandler) getSeries(w http.ResponseWriter, r *http.Request, id int) {
+	series, err := h.store.GetSeries(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// createSeries handles POST /series
+func (h *SeriesHandler) createSeries(w http.ResponseWriter, r *http.Request) {
+	var series models.Series
+	if err := json.NewDecoder(r.Body).Decode(&series); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	series.Name = strings.TrimSpace(series.Name)
+	if err := validateSeries(series); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	created, err := h.store.CreateSeries(r.Context(), series)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// updateSeries handles PUT /series/{id}
+func (h *SeriesHandler) updateSeries(w http.ResponseWriter, r *http.Request, id int) {
+	var series models.Series
+	if err := json.NewDecoder(r.Body).Decode(&series); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	series.ID = id
+
+	series.Name = strings.TrimSpace(series.Name)
+	if err := validateSeries(series); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	updated, err := h.store.UpdateSeries(r.Context(), series)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// deleteSeries handles DELETE /series/{id}
+func (h *SeriesHandler) deleteSeries(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.store.DeleteSeries(r.Context(), id); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// nextUnread handles GET /series/{id}/next: the first book in reading
+// order that is still to read or being read. Unnumbered books only come
+// up once every numbered one is read.
+func (h *SeriesHandler) nextUnread(w http.ResponseWriter, r *http.Request, id int) {
+	if _, err := h.store.GetSeries(r.Context(), id); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	page, err := h.store.GetByFilters(r.Context(), models.BookFilter{
+		SeriesID: id,
+		Where: &filterql.Comparison{
+			Field:  "status",
+			Op:     filterql.OpIn,
+			Values: []string{string(models.StatusToRead), string(models.StatusReading)},
+		},
+		Sort:  []filterql.SortField{{Field: "series_position"}},
+		Limit: 1,
+	})
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	if len(page.Books) == 0 {
+		storeErrorResponse(w, fmt.Errorf("unread book in series %d %w", id, models.ErrNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Books[0])
+}
+
+// validateSeries validates a series before it is saved
+func validateSeries(series models.Series) error {
+	if series.Name == "" {
+		return models.NewValidationError("Name", "name is required")
+	}
+
+	if len(series.Name) > maxSeriesNameLength {
+		return models.NewValidationError("Name", "name is too long")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// sessionRequest is the body of the start and stop endpoints. Reader is
+// required: sessions are tracked per reader, so readers without a name
+// would all share one running session.
+type sessionRequest struct {
+	Reader    string
+	PagesRead int
+	Note      string
+}
+
+// decodeSessionRequest reads a sessionRequest body
+func decodeSessionRequest(r *http.Request) (sessionRequest, error) {
+	var req sessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return req, err
+	}
+	req.Reader = strings.TrimSpace(req.Reader)
+	return req, nil
+}
+
+// startSession handles POST /books/{id}/sessions/start
+func (h *BookHandler) startSession(w http.ResponseWriter, r *http.Request, id int) {
+	req, err := decodeSessionRequest(r)
+	if err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if req.Reader == "" {
+		storeErrorResponse(w, models.NewValidationError("Reader", "Reader is required"))
+		return
+	}
+
+	session, err := h.store.StartSession(r.Context(), id, req.Reader, req.Note)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// stopSession handles POST /books/{id}/sessions/stop
+func (h *BookHandler) stopSession(w http.ResponseWriter, r *http.Request, id int) {
+	req, err := decodeSessionRequest(r)
+	if err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if req.Reader == "" {
+		storeErrorResponse(w, models.NewValidationError("Reader", "Reader is required"))
+		return
+	}
+
+	if req.PagesRead < 0 {
+		storeErrorResponse(w, models.NewValidationError("PagesRead", "pages read cannot be negative"))
+		return
+	}
+
+	session, err := h.store.StopSession(r.Context(), id, req.Reader, req.PagesRead, req.Note)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// logSession handles POST /books/{id}/sessions for sessions logged after the fact
+func (h *BookHandler) logSession(w http.ResponseWriter, r *http.Request, id int) {
+	var session models.ReadingSession
+	if err := json.NewDecoder(r.Body).Decode(&session); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	session.Reader = strings.TrimSpace(session.Reader)
+
+	if err := validateSession(session); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	session, err := h.store.LogSession(r.Context(), id, session)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// getSessions handles GET /books/{id}/sessions
+func (h *BookHandler) getSessions(w http.ResponseWriter, r *http.Request, id int) {
+	sessions, err := h.store.GetSessions(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// validateSession validates a manually logged session
+func validateSession(session models.ReadingSession) error {
+	if session.Reader == "" {
+		return models.NewValidationError("Reader", "Reader is required")
+	}
+
+	if session.StartedAt.IsZero() {
+		return models.NewValidationError("StartedAt", "StartedAt is required")
+	}
+
+	if session.EndedAt == nil {
+		return models.NewValidationError("EndedAt", "EndedAt is required")
+	}
+
+	if !session.EndedAt.After(session.StartedAt) {
+		return models.NewValidationError("EndedAt", "session must end after it starts")
+	}
+
+	if session.EndedAt.After(time.Now()) {
+		return models.NewValidationError("EndedAt", "session cannot end in the future")
+	}
+
+	if session.PagesRead < 0 {
+		return models.NewValidationError("PagesRead", "pages read cannot be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// TestSessionsChangeETag checks that a cached book is not served as
+// unmodified after a session changes its stats
+func TestSessionsChangeETag(t *testing.T) {
+	s := newTestStore(t)
+	h := NewBookHandler(s)
+
+	book, err := s.Create(context.Background(), models.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: models.StatusReading, StartDate: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	target := "/books/" + strconv.Itoa(book.ID)
+
+	ended := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	logged := `{"Reader":"ann","StartedAt":"` + ended.Add(-time.Hour).Format(time.RFC3339) +
+		`","EndedAt":"` + ended.Format(time.RFC3339) + `","PagesRead":20}`
+
+	steps := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"start", "/sessions/start", `{"Reader":"ann"}`, http.StatusCreated},
+		{"stop", "/sessions/stop", `{"Reader":"ann","PagesRead":10}`, http.StatusOK},
+		{"log", "/sessions", logged, http.StatusCreated},
+	}
+
+	etag := serve(h, http.MethodGet, target, "", nil).Header().Get("ETag")
+	for _, step := range steps {
+		if w := serve(h, http.MethodPost, target+step.path, step.body, nil); w.Code != step.status {
+			t.Fatalf("%s: status %d, want %d: %s", step.name, w.Code, step.status, w.Body)
+		}
+
+		w := serve(h, http.MethodGet, target, "", http.Header{"If-None-Match": {etag}})
+		if step.name == "start" {
+			// A running session is not part of the stats
+			if w.Code != http.StatusNotModified {
+				t.Errorf("%s: status %d, want %d", step.name, w.Code, http.StatusNotModified)
+			}
+			continue
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d with the old ETag, want %d", step.name, w.Code, http.StatusOK)
+		}
+		etag = w.Header().Get("ETag")
+	}
+}
+
+// TestSessionsRequireReader checks that every session names its reader,
+// since readers share nothing but their name
+func TestSessionsRequireReader(t *testing.T) {
+	s := newTestStore(t)
+	h := NewBookHandler(s)
+
+	book, err := s.Create(context.Background(), models.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: models.StatusReading, StartDate: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	target := "/books/" + strconv.Itoa(book.ID) + "/sessions"
+
+	ended := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	logged := `{"Reader":" ","StartedAt":"` + ended.Add(-time.Hour).Format(time.RFC3339) +
+		`","EndedAt":"` + ended.Format(time.RFC3339) + `"}`
+
+	requests := []struct {
+		path string
+		body string
+	}{
+		{"/start", ""},
+		{"/start", `{"Note":"train ride"}`},
+		{"/stop", `{"PagesRead":10}`},
+		{"", logged},
+	}
+
+	for _, req := range requests {
+		w := serve(h, http.MethodPost, target+req.path, req.body, nil)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Reader is required") {
+			t.Errorf("POST %s %s: status %d, want %d: %s", req.path, req.body, w.Code, http.StatusBadRequest, w.Body)
+		}
+	}
+}
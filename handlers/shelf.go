@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/favxlaw/models"
+)
+
+// maxShelfNameLength caps the length of a shelf name
+const maxShelfNameLength = 100
+
+// ShelfStore defines the storage operations behind /shelves
+type ShelfStore interface {
+	ListShelves(ctx context.Context, visibility models.ShelfVisibility) ([]models.Shelf, error)
+	GetShelf(ctx context.Context, id int) (*models.Shelf, error)
+	CreateShelf(ctx context.Context, shelf models.Shelf) (models.Shelf, error)
+	UpdateShelf(ctx context.Context, shelf models.Shelf) (*models.Shelf, error)
+	DeleteShelf(ctx context.Context, id int) error
+	GetShelfBooks(ctx context.Context, shelfID int) ([]models.ShelfBook, error)
+	AddShelfBook(ctx context.Context, shelfID, bookID, position int) ([]models.ShelfBook, error)
+	MoveShelfBook(ctx context.Context, shelfID, bookID, position int) ([]models.ShelfBook, error)
+	RemoveShelfBook(ctx context.Context, shelfID, bookID int) ([]models.ShelfBook, error)
+}
+
+// ShelfHandler handles all shelf-related HTTP requests
+type ShelfHandler struct {
+	store ShelfStore
+}
+
+// NewShelfHandler creates a new shelf handler
+func NewShelfHandler(s ShelfStore) *ShelfHandler {
+	return &ShelfHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *ShelfHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/shelves" || r.URL.Path == "/shelves/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.listShelves(w, r)
+		case http.MethodPost:
+			h.createShelf(w, r)
+		default:
+			errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, rest, err := splitPath(r.URL.Path, "/shelves/")
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.getShelf(w, r, id)
+	case rest == "" && r.Method == http.MethodPut:
+		h.updateShelf(w, r, id)
+	case rest == "" && r.Method == http.MethodDelete:
+		h.deleteShelf(w, r, id)
+	case rest == "books" && r.Method == http.MethodGet:
+		h.getShelfBooks(w, r, id)
+	case rest == "books" && r.Method == http.MethodPost:
+		h.addShelfBook(w, r, id)
+	case strings.HasPrefix(rest, "books/") && r.Method == http.MethodPut:
+		h.moveShelfBook(w, r, id, strings.TrimPrefix(rest, "books/"))
+	case strings.HasPrefix(rest, "books/") && r.Method == http.MethodDelete:
+		h.removeShelfBook(w, r, id, strings.TrimPrefix(rest, "books/"))
+	case rest == "" || rest == "books" || strings.HasPrefix(rest, "books/"):
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listShelves handles GET /shelves, optionally ?visibility=public
+func (h *ShelfHandler) listShelves(w http.ResponseWriter, r *http.Request) {
+	visibility := models.ShelfVisibility(r.URL.Query().Get("visibility"))
+	if visibility != "" && !validVisibility(visibility) {
+		errorResponse(w, "visibility must be one of: private, public", http.StatusBadRequest)
+		return
+	}
+
+	shelves, err := h.store.ListShelves(r.Context(), visibility)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shelves)
+}
+
+// getShelf handles GET /shelves/{id}
+func (h *ShelfHandler) getShelf(w http.ResponseWriter, r *http.Request, id int) {
+	shelf, err := h.store.GetShelf(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shelf)
+}
+
+// createShelf handles POST /shelves
+func (h *ShelfHandler) createShelf(w http.ResponseWriter, r *http.Request) {
+	var shelf models.Shelf
+	if err := json.NewDecoder(r.Body).Decode(&shelf); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	shelf = normalizeShelf(shelf)
+	if err := validateShelf(shelf); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	created, err := h.store.CreateShelf(r.Context(), shelf)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// updateShelf handles PUT /shelves/{id}
+func (h *ShelfHandler) updateShelf(w http.ResponseWriter, r *http.Request, id int) {
+	var shelf models.Shelf
+	if err := json.NewDecoder(r.Body).Decode(&shelf); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	shelf.ID = id
+
+	shelf = normalizeShelf(shelf)
+	if err := validateShelf(shelf); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	updated, err := h.store.UpdateShelf(r.Context(), shelf)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// deleteShelf handles DELETE /shelves/{id}
+func (h *ShelfHandler) deleteShelf(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.store.DeleteShelf(r.Context(), id); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getShelfBooks handles GET /shelves/{id}/books
+func (h *ShelfHandler) getShelfBooks(w http.ResponseWriter, r *http.Request, id int) {
+	entries, err := h.store.GetShelfBooks(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// addShelfBook handles POST /shelves/{id}/books. Without a Position the
+// book goes at the end of the shelf.
+func (h *ShelfHandler) addShelfBook(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		BookID   int
+		Position int
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if body.BookID <= 0 {
+		errorResponse(w, "BookID is required", http.StatusBadRequest)
+		return
+	}
+	if body.Position < 0 {
+		storeErrorResponse(w, models.NewValidationError("Position", "position cannot be negative"))
+		return
+	}
+
+	entries, err := h.store.AddShelfBook(r.Context(), id, body.BookID, body.Position)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// moveShelfBook handles PUT /shelves/{id}/books/{bookID}
+func (h *ShelfHandler) moveShelfBook(w http.ResponseWriter, r *http.Request, id int, bookID string) {
+	bid, err := strconv.Atoi(bookID)
+	if err != nil {
+		errorResponse(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Position int
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if body.Position <= 0 {
+		storeErrorResponse(w, models.NewValidationError("Position", "position must be at least 1"))
+		return
+	}
+
+	entries, err := h.store.MoveShelfBook(r.Context(), id, bid, body.Position)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// removeShelfBook handles DELETE /shelves/{id}/books/{bookID}
+func (h *ShelfHandler) removeShelfBook(w http.ResponseWriter, r *http.Request, id int, bookID string) {
+	bid, err := strconv.Atoi(bookID)
+	if err != nil {
+		errorResponse(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.store.RemoveShelfBook(r.Context(), id, bid)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// normalizeShelf trims the name and defaults the visibility to private
+func normalizeShelf(shelf models.Shelf) models.Shelf {
+	shelf.Name = strings.TrimSpace(shelf.Name)
+	if shelf.Visibility == "" {
+		shelf.Visibility = models.VisibilityPrivate
+	}
+	return shelf
+}
+
+// validateShelf validates a shelf before it is saved
+func validateShelf(shelf models.Shelf) error {
+	if shelf.Name == "" {
+		return models.NewValidationError("Name", "name is required")
+	}
+
+	if len(shelf.Name) > maxShelfNameLength {
+		return models.NewValidationError("Name", "name is too long")
+	}
+
+	if !validVisibility(shelf.Visibility) {
+		return models.NewValidationError("Visibility", "visibility must be one of: private, public")
+	}
+
+	return nil
+}
+
+// validVisibility reports whether v is a known shelf visibility
+func validVisibility(v models.ShelfVisibility) bool {
+	return v == models.VisibilityPrivate || v == models.VisibilityPublic
+}
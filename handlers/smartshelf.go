@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/favxlaw/models"
+)
+
+// SmartShelfStore defines the storage operations behind /smart-shelves
+type SmartShelfStore interface {
+	ListSmartShelves(ctx context.Context) ([]models.SmartShelf, error)
+	GetSmartShelf(ctx context.Context, id int) (*models.SmartShelf, error)
+	CreateSmartShelf(ctx context.Context, shelf models.SmartShelf) (models.SmartShelf, error)
+	UpdateSmartShelf(ctx context.Context, shelf models.SmartShelf) (*models.SmartShelf, error)
+	DeleteSmartShelf(ctx context.Context, id int) error
+	GetSmartShelfBooks(ctx context.Context, id int, page models.BookFilter) (models.BookPage, error)
+}
+
+// SmartShelfHandler handles all smart-shelf-related HTTP requests
+type SmartShelfHandler struct {
+	store SmartShelfStore
+}
+
+// NewSmartShelfHandler creates a new smart shelf handler
+func NewSmartShelfHandler(s SmartShelfStore) *SmartShelfHandler {
+	return &SmartShelfHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *SmartShelfHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/smart-shelves" || r.URL.Path == "/smart-shelves/" {
+		switch r.Method {
+		case http.MethodGet:
+			h.listSmartShelves(w, r)
+		case http.MethodPost:
+			h.createSmartShelf(w, r)
+		default:
+			errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, rest, err := splitPath(r.URL.Path, "/smart-shelves/")
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.getSmartShelf(w, r, id)
+	case rest == "" && r.Method == http.MethodPut:
+		h.updateSmartShelf(w, r, id)
+	case rest == "" && r.Method == http.MethodDelete:
+		h.deleteSmartShelf(w, r, id)
+	case rest == "books" && r.Method == http.MethodGet:
+		h.getSmartShelfBooks(w, r, id)
+	case rest == "" || rest == "books":
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listSmartShelves handles GET /smart-shelves, with current book counts
+func (h *SmartShelfHandler) listSmartShelves(w http.ResponseWriter, r *http.Request) {
+	shelves, err := h.store.ListSmartShelves(r.Context())
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shelves)
+}
+
+// getSmartShelf handles GET /smart-shelves/{id}
+func (h *SmartShelfHandler) getSmartShelf(w http.ResponseWriter, r *http.Request, id int) {
+	shelf, err := h.store.GetSmartShelf(r.Context(), id)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shelf)
+}
+
+// createSmartShelf handles POST /smart-shelves
+func (h *SmartShelfHandler) createSmartShelf(w http.ResponseWriter, r *http.Request) {
+	var shelf models.SmartShelf
+	if err := json.NewDecoder(r.Body).Decode(&shelf); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	shelf = normalizeSmartShelf(shelf)
+	if err := validateSmartShelf(shelf); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	created, err := h.store.CreateSmartShelf(r.Context(), shelf)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// updateSmartShelf handles PUT /smart-shelves/{id}
+func (h *SmartShelfHandler) updateSmartShelf(w http.ResponseWriter, r *http.Request, id int) {
+	var shelf models.SmartShelf
+	if err := json.NewDecoder(r.Body).Decode(&shelf); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	shelf.ID = id
+
+	shelf = normalizeSmartShelf(shelf)
+	if err := validateSmartShelf(shelf); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	updated, err := h.store.UpdateSmartShelf(r.Context(), shelf)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// deleteSmartShelf handles DELETE /smart-shelves/{id}
+func (h *SmartShelfHandler) deleteSmartShelf(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.store.DeleteSmartShelf(r.Context(), id); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSmartShelfBooks handles GET /smart-shelves/{id}/books, paginated
+// like GET /books
+func (h *SmartShelfHandler) getSmartShelfBooks(w http.ResponseWriter, r *http.Request, id int) {
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page := models.BookFilter{Limit: limit, CountTotal: query.Get("count") == "true"}
+
+	if token := query.Get("cursor"); token != "" {
+		page.After, err = decodeCursor(token)
+		if err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.store.GetSmartShelfBooks(r.Context(), id, page)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	setPageHeaders(w, r, result)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result.Books)
+}
+
+// normalizeSmartShelf trims the name and maps legacy sort names
+func normalizeSmartShelf(shelf models.SmartShelf) models.SmartShelf {
+	shelf.Name = strings.TrimSpace(shelf.Name)
+	if alias, ok := legacySorts[shelf.Sort]; ok {
+		shelf.Sort = alias
+	}
+	return shelf
+}
+
+// validateSmartShelf validates a smart shelf before it is saved. The
+// store checks the query itself.
+func validateSmartShelf(shelf models.SmartShelf) error {
+	if shelf.Name == "" {
+		return models.NewValidationError("Name", "name is required")
+	}
+
+	if len(shelf.Name) > maxShelfNameLength {
+		return models.NewValidationError("Name", "name is too long")
+	}
+
+	return models.ValidateTags(shelf.Tags)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/favxlaw/store"
+)
+
+func TestMain(m *testing.M) {
+	// Migrations log every step
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestStore opens a migrated store in a temporary directory. Tests
+// using it are skipped when the driver was built without FTS5.
+func newTestStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	dir := t.TempDir()
+	s, err := store.NewSQLiteStore(filepath.Join(dir, "test.db"), filepath.Join(dir, "covers"))
+	if errors.Is(err, store.ErrNoFTS5) {
+		t.Skip("needs -tags sqlite_fts5")
+	}
+	if err != nil {
+		t.Fatalf("NewSQLiteStore error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// serve sends a request to h and returns the recorded response
+func serve(h http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/favxlaw/models"
+)
+
+// TagStore defines the storage operations behind /tags
+type TagStore interface {
+	ListTags(ctx context.Context) ([]models.Tag, error)
+	RenameTag(ctx context.Context, id int, name string) (*models.Tag, error)
+	MergeTags(ctx context.Context, targetID int, sourceIDs []int) (*models.Tag, error)
+	DeleteTag(ctx context.Context, id int) error
+}
+
+// TagHandler handles all tag-related HTTP requests
+type TagHandler struct {
+	store TagStore
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(s TagStore) *TagHandler {
+	return &TagHandler{store: s}
+}
+
+// ServeHTTP implements http.Handler interface
+func (h *TagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/tags" || r.URL.Path == "/tags/" {
+		if r.Method != http.MethodGet {
+			errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.listTags(w, r)
+		return
+	}
+
+	id, rest, err := splitPath(r.URL.Path, "/tags/")
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case rest == "" && r.Method == http.MethodPut:
+		h.renameTag(w, r, id)
+	case rest == "" && r.Method == http.MethodDelete:
+		h.deleteTag(w, r, id)
+	case rest == "merge" && r.Method == http.MethodPost:
+		h.mergeTags(w, r, id)
+	case rest == "" || rest == "merge":
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		errorResponse(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listTags handles GET /tags, including how many books carry each tag
+func (h *TagHandler) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.store.ListTags(r.Context())
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// renameTag handles PUT /tags/{id}
+func (h *TagHandler) renameTag(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		Name string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.ValidateTags([]string{body.Name}); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	tag, err := h.store.RenameTag(r.Context(), id, body.Name)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// mergeTags handles POST /tags/{id}/merge, folding the listed tags into this one
+func (h *TagHandler) mergeTags(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		TagIDs []int
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if len(body.TagIDs) == 0 {
+		errorResponse(w, "TagIDs is required", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.store.MergeTags(r.Context(), id, body.TagIDs)
+	if err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// deleteTag handles DELETE /tags/{id}
+func (h *TagHandler) deleteTag(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.store.DeleteTag(r.Context(), id); err != nil {
+		storeErrorResponse(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,184 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/calibre"
+	"github.com/favxlaw/isbn"
+	"github.com/favxlaw/models"
+)
+
+// calibreLanguages maps the ISO 639-2 codes Calibre stores onto the
+// shorter ISO 639-1 codes where one exists. Other codes are kept as they
+// are; three letters are valid language codes too.
+var calibreLanguages = map[string]string{
+	"ara": "ar", "ces": "cs", "cze": "cs", "chi": "zh", "zho": "zh",
+	"dan": "da", "deu": "de", "ger": "de", "dut": "nl", "nld": "nl",
+	"ell": "el", "gre": "el", "eng": "en", "fin": "fi", "fra": "fr",
+	"fre": "fr", "heb": "he", "hin": "hi", "hun": "hu", "ita": "it",
+	"jpn": "ja", "kor": "ko", "nor": "no", "nob": "nb", "pol": "pl",
+	"por": "pt", "ron": "ro", "rum": "ro", "rus": "ru", "spa": "es",
+	"swe": "sv", "tur": "tr", "ukr": "uk",
+}
+
+// calibreAudioFormats are the Calibre formats that make a book an
+// audiobook; any other file makes it an ebook
+var calibreAudioFormats = map[string]bool{
+	"M4B": true, "M4A": true, "MP3": true, "AAC": true, "OGG": true, "OPUS": true, "FLAC": true,
+}
+
+// CalibreReport is an import report with notes on how the Calibre
+// metadata was mapped, keyed by Calibre book ID
+type CalibreReport struct {
+	models.ImportReport
+	Notes map[int][]string
+}
+
+// Calibre imports books read from a Calibre library. The Calibre book
+// ID stands in for the line number in the report. Books are matched by
+// ISBN, then by title and author, so a library can be imported again to
+// pick up changes. Series are found or created by name; tags are added
+// to the ones a book already has. Status, start date and format are only
+// set on new books, since Calibre doesn't track reading.
+func Calibre(ctx context.Context, s Store, books []calibre.Book, dryRun bool) (CalibreReport, error) {
+	notes := make(map[int][]string)
+	rows := make([]Row, 0, len(books))
+
+	for _, cb := range books {
+		mapped, bookNotes := mapCalibreBook(cb)
+		if len(bookNotes) > 0 {
+			notes[cb.ID] = bookNotes
+		}
+
+		seriesName := strings.TrimSpace(cb.Series)
+		rows = append(rows, Row{
+			Line:       cb.ID,
+			ISBN:       mapped.ISBN,
+			Title:      mapped.Title,
+			Author:     mapped.Author,
+			MatchTitle: true,
+			SeriesName: seriesName,
+			Apply: func(book *models.Book) error {
+				applyCalibreBook(book, mapped, seriesName != "")
+				return nil
+			},
+		})
+	}
+
+	report, err := Run(ctx, s, rows, models.ImportReport{}, dryRun)
+	if err != nil {
+		return CalibreReport{}, err
+	}
+	return CalibreReport{ImportReport: report, Notes: notes}, nil
+}
+
+// mapCalibreBook converts a Calibre book into ours, noting whatever had
+// to be dropped or translated on the way
+func mapCalibreBook(cb calibre.Book) (models.Book, []string) {
+	var notes []string
+	book := models.Book{
+		Title:           strings.TrimSpace(cb.Title),
+		Author:          strings.Join(cb.Authors, " & "),
+		Publisher:       strings.TrimSpace(cb.Publisher),
+		PublicationYear: cb.PublicationYear,
+	}
+
+	for _, tag := range cb.Tags {
+		// ; separates our tags in CSV files
+		if strings.Contains(tag, ";") {
+			notes = append(notes, fmt.Sprintf("tag %q imported with commas for semicolons", tag))
+			tag = strings.ReplaceAll(tag, ";", ",")
+		}
+		book.Tags = append(book.Tags, tag)
+	}
+
+	if cb.ISBN != "" {
+		normalized, err := isbn.Normalize(cb.ISBN)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("ISBN %s dropped: %v", cb.ISBN, err))
+		} else {
+			book.ISBN = normalized
+		}
+	}
+
+	if len(cb.Languages) > 0 {
+		code := strings.ToLower(cb.Languages[0])
+		if short, ok := calibreLanguages[code]; ok {
+			code = short
+		}
+		if models.IsLanguageCode(code) {
+			book.Language = code
+		} else {
+			notes = append(notes, fmt.Sprintf("language %q dropped", cb.Languages[0]))
+		}
+		if len(cb.Languages) > 1 {
+			notes = append(notes, fmt.Sprintf("only the first language kept of %s", strings.Join(cb.Languages, ", ")))
+		}
+	}
+
+	if cb.Rating > 0 {
+		// Calibre counts half stars
+		rating := float64(cb.Rating) / 2
+		book.Rating = &rating
+	}
+
+	if cb.Series != "" {
+		position := cb.SeriesIndex
+		book.SeriesPosition = &position
+	}
+
+	if len(cb.Formats) > 0 {
+		book.Format = models.FormatAudiobook
+		for _, format := range cb.Formats {
+			if !calibreAudioFormats[strings.ToUpper(format)] {
+				book.Format = models.FormatEbook
+				break
+			}
+		}
+	}
+
+	if cb.Added != "" {
+		if added, err := time.Parse("2006-01-02", cb.Added); err == nil {
+			book.StartDate = added
+		}
+	}
+
+	return book, notes
+}
+
+// applyCalibreBook copies a mapped Calibre book onto a new or existing
+// book. Fields Calibre leaves empty keep their current values.
+func applyCalibreBook(book *models.Book, mapped models.Book, inSeries bool) {
+	isNew := book.ID == 0
+
+	book.Title = mapped.Title
+	book.Author = mapped.Author
+	if mapped.ISBN != "" {
+		book.ISBN = mapped.ISBN
+	}
+	if mapped.Publisher != "" {
+		book.Publisher = mapped.Publisher
+	}
+	if mapped.PublicationYear != 0 {
+		book.PublicationYear = mapped.PublicationYear
+	}
+	if mapped.Language != "" {
+		book.Language = mapped.Language
+	}
+	if mapped.Rating != nil {
+		book.Rating = mapped.Rating
+	}
+	if inSeries {
+		book.SeriesPosition = mapped.SeriesPosition
+	}
+	book.Tags = append(append([]string{}, book.Tags...), mapped.Tags...)
+
+	if isNew {
+		book.Status = models.StatusToRead
+		book.StartDate = mapped.StartDate
+		book.Format = mapped.Format
+	}
+}
@@ -0,0 +1,217 @@
+// Package importer saves books read from import files. Each row is
+// matched against the library, applied to the book it matches or to a
+// new one, validated like POST /books and then saved together with the
+// rest of the file in one store transaction. The HTTP import endpoints
+// and the command line both import through it.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/isbn"
+	"github.com/favxlaw/models"
+)
+
+// Store defines the storage operations an import needs
+type Store interface {
+	GetByID(ctx context.Context, id int) (*models.Book, error)
+	MatchBook(ctx context.Context, isbn, title, author string) (*models.Book, error)
+	ImportBooks(ctx context.Context, rows []models.ImportRow, commit bool) ([]models.ImportResult, error)
+}
+
+// Row is an import row before it is matched against the library. Apply
+// writes the row's fields onto a new or existing book.
+type Row struct {
+	Line   int
+	ID     int
+	ISBN   string
+	Title  string
+	Author string
+	Apply  func(book *models.Book) error
+
+	// MatchTitle also matches books by exact title and author when the
+	// ISBN finds nothing
+	MatchTitle bool
+
+	// SetsEndDate is true when the row brings its own end date
+	SetsEndDate bool
+
+	// SetsWorkID is true when the row brings its own work ID
+	SetsWorkID bool
+
+	// SeriesName names the book's series instead of a SeriesID; the store
+	// resolves it, creating the series if needed
+	SeriesName string
+}
+
+// Run matches rows against the library, validates the resulting books
+// and hands them to the store in one transaction. report may already
+// hold rows rejected while reading the file; they also keep the import
+// from being committed.
+func Run(ctx context.Context, s Store, rows []Row, report models.ImportReport, dryRun bool) (models.ImportReport, error) {
+	results := report.Rows
+	resolved := make([]models.ImportRow, 0, len(rows))
+
+	for _, row := range rows {
+		imported, err := resolveRow(ctx, s, row)
+		if err != nil {
+			var validationErr *models.ValidationError
+			if !errors.As(err, &validationErr) && !errors.Is(err, models.ErrNotFound) {
+				return report, err
+			}
+			results = append(results, models.ImportResult{Line: row.Line, Action: models.ImportRejected,
+				BookID: row.ID, Title: row.Title, Error: ErrorMessage(err)})
+			continue
+		}
+		resolved = append(resolved, imported)
+	}
+
+	rejected := len(results) > 0
+	if len(resolved) > 0 {
+		saved, err := s.ImportBooks(ctx, resolved, !dryRun && !rejected)
+		if err != nil {
+			return report, err
+		}
+		results = append(results, saved...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+	report = models.ImportReport{DryRun: dryRun}
+	for _, result := range results {
+		report.Add(result)
+	}
+	return report, nil
+}
+
+// ErrorMessage is the message reported for a rejected row
+func ErrorMessage(err error) string {
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Message
+	}
+	return err.Error()
+}
+
+// resolveRow builds the book a row saves: the row applied to the book it
+// matches, or to a new book with the same defaults as POST /books
+func resolveRow(ctx context.Context, s Store, row Row) (models.ImportRow, error) {
+	imported := models.ImportRow{Line: row.Line, SeriesName: row.SeriesName}
+	existing, foreign, err := matchRow(ctx, s, row)
+	if err != nil {
+		return imported, err
+	}
+
+	book := &imported.Book
+	if existing != nil {
+		*book = *existing
+	}
+	if err := row.Apply(book); err != nil {
+		return imported, err
+	}
+
+	// Rows with the same work ID belong together, and the store keeps
+	// them together. A foreign row's work ID means nothing here, so it
+	// only groups rows and the book otherwise keeps its own work.
+	if row.SetsWorkID && book.WorkID != 0 {
+		imported.WorkKey = book.WorkID
+		if foreign {
+			book.WorkID = 0
+			if existing != nil {
+				book.WorkID = existing.WorkID
+			}
+		}
+	}
+
+	// A named series has no ID until the store resolves it, so its
+	// position is set aside while the rest of the book is checked
+	position := book.SeriesPosition
+	if row.SeriesName != "" {
+		book.SeriesID, book.SeriesPosition = nil, nil
+	}
+	if err := models.ValidateBook(book); err != nil {
+		return imported, err
+	}
+	if row.SeriesName != "" {
+		if position != nil && *position < 0 {
+			return imported, models.NewValidationError("SeriesPosition", "series position cannot be negative")
+		}
+		book.SeriesPosition = position
+	}
+
+	if existing == nil {
+		book.ID = 0
+		if book.StartDate.IsZero() && book.EndDate != nil {
+			book.StartDate = *book.EndDate
+		}
+		if book.StartDate.IsZero() {
+			book.StartDate = time.Now()
+		}
+		if book.Status == "" {
+			book.Status = models.StatusToRead
+		}
+		return imported, nil
+	}
+
+	// Same rules as PUT and PATCH: editing the display string re-derives credits,
+	// and the status decides the end date unless the row sets one
+	models.ApplyAuthorEdit(book, existing)
+	if book.Status == "" {
+		book.Status = existing.Status
+	}
+	if !row.SetsEndDate {
+		models.ApplyEndDate(book, existing)
+	}
+	book.ID = existing.ID
+	book.Version = existing.Version
+	return imported, nil
+}
+
+// matchRow finds the book a row updates: by ID, else by ISBN, else by
+// title and author when the row allows it. nil means the row creates a
+// new book.
+//
+// IDs from another library's export may be missing here or belong to a
+// different book, so an ID only counts when that book also has the row's
+// ISBN or title, if the row has either. Otherwise the row is foreign and
+// is matched by ISBN, title and author instead.
+func matchRow(ctx context.Context, s Store, row Row) (book *models.Book, foreign bool, err error) {
+	normalized := ""
+	if row.ISBN != "" {
+		normalized, err = isbn.Normalize(row.ISBN)
+		if err != nil {
+			return nil, false, models.NewValidationError("ISBN", err.Error())
+		}
+	}
+
+	if row.ID != 0 {
+		book, err := s.GetByID(ctx, row.ID)
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			return nil, false, fmt.Errorf("book %d: %w", row.ID, err)
+		}
+		if book != nil && sameBook(book, normalized, row.Title) {
+			return book, false, nil
+		}
+		foreign = true
+	}
+
+	title, author := "", ""
+	if row.MatchTitle || foreign {
+		title, author = row.Title, row.Author
+	}
+	book, err = s.MatchBook(ctx, normalized, title, author)
+	return book, foreign, err
+}
+
+// sameBook reports whether book could be the one a row with this ISBN
+// and title describes. A row with neither can't tell.
+func sameBook(book *models.Book, isbn, title string) bool {
+	if isbn == "" && title == "" {
+		return true
+	}
+	return (isbn != "" && isbn == book.ISBN) || (title != "" && strings.EqualFold(title, book.Title))
+}
@@ -0,0 +1,97 @@
+// Package isbn validates ISBN-10 and ISBN-13 numbers and normalizes them
+// to the 13-digit form, so the same edition always has the same key no
+// matter how it was typed:
+//
+//	0-441-01359-7, 0441013597, 978-0-441-01359-3 -> 9780441013593
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors returned by Normalize
+var (
+	ErrLength   = errors.New("ISBN must have 10 or 13 digits")
+	ErrChar     = errors.New("ISBN may only contain digits, hyphens, spaces and a final X")
+	ErrChecksum = errors.New("ISBN check digit is wrong")
+	ErrPrefix   = errors.New("ISBN-13 must start with 978 or 979")
+)
+
+// Normalize checks an ISBN-10 or ISBN-13 and returns it as 13 digits
+// without separators
+func Normalize(s string) (string, error) {
+	var digits strings.Builder
+	for _, c := range strings.TrimSpace(s) {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c == 'X' || c == 'x':
+			digits.WriteByte('X')
+		case c == '-' || c == ' ':
+		default:
+			return "", ErrChar
+		}
+	}
+	raw := digits.String()
+
+	// X is only valid as the check digit of an ISBN-10
+	if i := strings.IndexByte(raw, 'X'); i >= 0 && (len(raw) != 10 || i != 9) {
+		return "", ErrChar
+	}
+
+	switch len(raw) {
+	case 10:
+		if checkDigit10(raw[:9]) != raw[9] {
+			return "", ErrChecksum
+		}
+		body := "978" + raw[:9]
+		return body + string(checkDigit13(body)), nil
+	case 13:
+		if !strings.HasPrefix(raw, "978") && !strings.HasPrefix(raw, "979") {
+			return "", ErrPrefix
+		}
+		if checkDigit13(raw[:12]) != raw[12] {
+			return "", ErrChecksum
+		}
+		return raw, nil
+	default:
+		return "", ErrLength
+	}
+}
+
+// To10 converts a normalized ISBN-13 back to an ISBN-10. Only 978
+// numbers have one; ok is false for 979 numbers.
+func To10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+	body := isbn13[3:12]
+	return body + string(checkDigit10(body)), true
+}
+
+// checkDigit10 computes the ISBN-10 check digit of 9 digits
+func checkDigit10(body string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// checkDigit13 computes the ISBN-13 check digit of 12 digits
+func checkDigit13(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(body[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
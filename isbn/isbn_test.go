@@ -0,0 +1,85 @@
+package isbn
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"9780441013593", "9780441013593"},
+		{"978-0-441-01359-3", "9780441013593"},
+		{"978 0 441 01359 3", "9780441013593"},
+		{"  9780441013593  ", "9780441013593"},
+		{"0441013597", "9780441013593"},
+		{"0-441-01359-7", "9780441013593"},
+		{"0306406152", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"0-8044-2957-x", "9780804429573"},
+		{"9791234567896", "9791234567896"},
+		{"979-10-90636-07-1", "9791090636071"},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.input)
+		if err != nil {
+			t.Errorf("Normalize(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrLength},
+		{"---", ErrLength},
+		{"044101359", ErrLength},
+		{"04410135977", ErrLength},
+		{"97804410135933", ErrLength},
+		{"0441013598", ErrChecksum},
+		{"0306406153", ErrChecksum},
+		{"9780441013594", ErrChecksum},
+		{"9791234567890", ErrChecksum},
+		{"0804429570", ErrChecksum},
+		{"1234567890123", ErrPrefix},
+		{"0-441.01359-7", ErrChar},
+		{"ISBN 0441013597", ErrChar},
+		{"０441013597", ErrChar},
+		{"X441013597", ErrChar},
+		{"978044101359X", ErrChar},
+		{"08044295X7", ErrChar},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.input)
+		if err != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want error %v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestTo10(t *testing.T) {
+	tests := []struct {
+		isbn13 string
+		want   string
+		ok     bool
+	}{
+		{"9780441013593", "0441013597", true},
+		{"9780804429573", "080442957X", true},
+		{"9791234567896", "", false},
+		{"978044101359", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := To10(tt.isbn13)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("To10(%q) = %q, %v; want %q, %v", tt.isbn13, got, ok, tt.want, tt.ok)
+		}
+	}
+}
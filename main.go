@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/favxlaw/config"
@@ -18,33 +20,85 @@ func main() {
 		log.Fatalf("Configuration error: %v", err)
 	}
 
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(cfg, os.Args[1:]))
+	}
+
 	log.Printf("Starting Book Tracker API")
 	log.Printf("Configuration:")
 	log.Printf("  Port: %s", cfg.Port)
 	log.Printf("  Database: %s", cfg.DBPath)
 	log.Printf("  Log Level: %s", cfg.LogLevel)
+	log.Printf("  Covers: %s", cfg.CoverDir)
+	log.Printf("  Backups: %s (keeping %d days)", cfg.BackupDir, cfg.BackupKeep)
 	log.Println()
 
-	bookStore, err := store.NewSQLiteStore(cfg.DBPath)
+	bookStore, err := store.NewSQLiteStore(cfg.DBPath, cfg.CoverDir)
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer bookStore.Close()
 
-	seedBooks(bookStore)
+	if err := seedBooks(context.Background(), bookStore); err != nil {
+		log.Fatal("Failed to seed database:", err)
+	}
 
 	bookHandler := handlers.NewBookHandler(bookStore)
+	authorHandler := handlers.NewAuthorHandler(bookStore)
+	tagHandler := handlers.NewTagHandler(bookStore)
+	highlightHandler := handlers.NewHighlightHandler(bookStore)
+	shelfHandler := handlers.NewShelfHandler(bookStore)
+	smartShelfHandler := handlers.NewSmartShelfHandler(bookStore)
+	loanHandler := handlers.NewLoanHandler(bookStore)
+	seriesHandler := handlers.NewSeriesHandler(bookStore)
+	importHandler := handlers.NewImportHandler(bookStore)
+	exportHandler := handlers.NewExportHandler(bookStore)
+	adminHandler := handlers.NewAdminHandler(bookStore, cfg.BackupDir, cfg.BackupKeep)
 
 	http.Handle("/books", bookHandler)
 	http.Handle("/books/", bookHandler)
+	http.Handle("/authors", authorHandler)
+	http.Handle("/authors/", authorHandler)
+	http.Handle("/tags", tagHandler)
+	http.Handle("/tags/", tagHandler)
+	http.Handle("/highlights/", highlightHandler)
+	http.Handle("/shelves", shelfHandler)
+	http.Handle("/shelves/", shelfHandler)
+	http.Handle("/smart-shelves", smartShelfHandler)
+	http.Handle("/smart-shelves/", smartShelfHandler)
+	http.Handle("/loans", loanHandler)
+	http.Handle("/loans/", loanHandler)
+	http.Handle("/series", seriesHandler)
+	http.Handle("/series/", seriesHandler)
+	http.Handle("/import/", importHandler)
+	http.Handle("/export", exportHandler)
+	http.Handle("/admin/", adminHandler)
 	http.HandleFunc("/", homeHandler)
 
 	fmt.Println("Server starting on http://localhost:" + cfg.Port)
 	fmt.Println("GET    /books       - List all books")
 	fmt.Println("POST   /books       - Add new book")
+	fmt.Println("GET    /books/search?q= - Full-text search")
 	fmt.Println("GET    /books/{id}  - Get specific book")
 	fmt.Println("PUT    /books/{id}  - Update book")
+	fmt.Println("PATCH  /books/{id}  - Partially update book")
 	fmt.Println("DELETE /books/{id}  - Delete book")
+	fmt.Println("GET    /authors     - List authors")
+	fmt.Println("GET    /authors/{id}/books - Books by author")
+	fmt.Println("GET    /tags        - List tags with counts")
+	fmt.Println("GET    /highlights/search?q= - Search highlights")
+	fmt.Println("GET    /highlights/daily - Highlight of the day")
+	fmt.Println("GET    /shelves     - List shelves")
+	fmt.Println("GET    /shelves/{id}/books - Books on a shelf")
+	fmt.Println("GET    /smart-shelves - List saved queries with counts")
+	fmt.Println("GET    /loans?overdue=true - Books lent out")
+	fmt.Println("GET    /series/{id} - Series in reading order")
+	fmt.Println("GET    /series/{id}/next - Next unread book in a series")
+	fmt.Println("POST   /import/csv?dry_run=true - Import books from CSV")
+	fmt.Println("POST   /import/jsonl - Import books from JSON Lines")
+	fmt.Println("POST   /import/goodreads - Import a Goodreads export")
+	fmt.Println("GET    /export?format=csv|jsonl|md|bibtex - Export books")
+	fmt.Println("POST   /admin/backup - Write a database snapshot")
 	fmt.Println()
 	fmt.Println("Press Ctrl+C to stop")
 
@@ -58,30 +112,37 @@ func main() {
 	}
 }
 
-func seedBooks(s *store.SQLiteStore) {
+func seedBooks(ctx context.Context, s *store.SQLiteStore) error {
 	// Only seed if database is empty
-	existing := s.GetAll()
+	existing, err := s.GetAll(ctx)
+	if err != nil {
+		return err
+	}
 	if len(existing) > 0 {
-		return
+		return nil
 	}
 
 	fmt.Println("Seeding initial data...")
 
-	s.Create(models.Book{
+	_, err = s.Create(ctx, models.Book{
 		Title:     "Clean Code",
 		Author:    "Robert C. Martin",
 		Status:    models.StatusToRead,
 		Category:  "Software Engineering",
 		StartDate: time.Now(),
 	})
+	if err != nil {
+		return err
+	}
 
-	s.Create(models.Book{
+	_, err = s.Create(ctx, models.Book{
 		Title:     "Dune",
 		Author:    "Frank Herbert",
 		Status:    models.StatusReading,
 		Category:  "Science Fiction",
 		StartDate: time.Now(),
 	})
+	return err
 }
 
 func homeHandler(w http.ResponseWriter, r *http.Request) {
@@ -94,7 +155,25 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Available Endpoints:\n")
 	fmt.Fprintf(w, "  GET    /books       - List all books\n")
 	fmt.Fprintf(w, "  POST   /books       - Add new book\n")
+	fmt.Fprintf(w, "  GET    /books/search?q= - Full-text search\n")
 	fmt.Fprintf(w, "  GET    /books/{id}  - Get specific book\n")
 	fmt.Fprintf(w, "  PUT    /books/{id}  - Update book\n")
+	fmt.Fprintf(w, "  PATCH  /books/{id}  - Partially update book\n")
 	fmt.Fprintf(w, "  DELETE /books/{id}  - Delete book\n")
+	fmt.Fprintf(w, "  GET    /authors     - List authors\n")
+	fmt.Fprintf(w, "  GET    /authors/{id}/books - Books by author\n")
+	fmt.Fprintf(w, "  GET    /tags        - List tags with counts\n")
+	fmt.Fprintf(w, "  GET    /highlights/search?q= - Search highlights\n")
+	fmt.Fprintf(w, "  GET    /highlights/daily - Highlight of the day\n")
+	fmt.Fprintf(w, "  GET    /shelves     - List shelves\n")
+	fmt.Fprintf(w, "  GET    /shelves/{id}/books - Books on a shelf\n")
+	fmt.Fprintf(w, "  GET    /smart-shelves - List saved queries with counts\n")
+	fmt.Fprintf(w, "  GET    /loans?overdue=true - Books lent out\n")
+	fmt.Fprintf(w, "  GET    /series/{id} - Series in reading order\n")
+	fmt.Fprintf(w, "  GET    /series/{id}/next - Next unread book in a series\n")
+	fmt.Fprintf(w, "  POST   /import/csv?dry_run=true - Import books from CSV\n")
+	fmt.Fprintf(w, "  POST   /import/jsonl - Import books from JSON Lines\n")
+	fmt.Fprintf(w, "  POST   /import/goodreads - Import a Goodreads export\n")
+	fmt.Fprintf(w, "  GET    /export?format=csv|jsonl|md|bibtex - Export books\n")
+	fmt.Fprintf(w, "  POST   /admin/backup - Write a database snapshot\n")
 }
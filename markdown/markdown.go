@@ -0,0 +1,227 @@
+// Package markdown renders the small Markdown subset used in reviews to
+// HTML that is safe to embed in a page:
+//
+//	# headings, paragraphs, > quotes, - and 1. lists, ``` code blocks, ---
+//	**strong**, *emphasis*, `code` and [links](https://example.com)
+//
+// Raw HTML is never passed through; it is escaped like any other text,
+// and links are only kept for http, https, mailto and relative URLs.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingLine   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	unorderedItem = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	orderedItem   = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	ruleLine      = regexp.MustCompile(`^\s{0,3}(-(\s*-){2,}|\*(\s*\*){2,}|_(\s*_){2,})\s*$`)
+)
+
+// ToHTML renders Markdown source as sanitized HTML
+func ToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	lines := strings.Split(src, "\n")
+
+	var out strings.Builder
+	renderBlocks(&out, lines)
+	return out.String()
+}
+
+// renderBlocks renders a sequence of lines as block elements
+func renderBlocks(out *strings.Builder, lines []string) {
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + renderInline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case headingLine.MatchString(trimmed):
+			flush()
+			m := headingLine.FindStringSubmatch(trimmed)
+			tag := "h" + string(rune('0'+len(m[1])))
+			out.WriteString("<" + tag + ">" + renderInline(m[2]) + "</" + tag + ">\n")
+
+		case ruleLine.MatchString(line):
+			flush()
+			out.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			out.WriteString("<blockquote>\n")
+			renderBlocks(out, quoted)
+			out.WriteString("</blockquote>\n")
+
+		case unorderedItem.MatchString(line), orderedItem.MatchString(line):
+			flush()
+			i = renderList(out, lines, i) - 1
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+
+	flush()
+}
+
+// renderList renders the list starting at lines[start] and returns the
+// index of the first line after it. Indented lines continue the
+// previous item.
+func renderList(out *strings.Builder, lines []string, start int) int {
+	pattern, tag := unorderedItem, "ul"
+	if orderedItem.MatchString(lines[start]) {
+		pattern, tag = orderedItem, "ol"
+	}
+
+	var items []string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := pattern.FindStringSubmatch(line); m != nil {
+			items = append(items, m[1])
+			continue
+		}
+		if strings.TrimSpace(line) != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			items[len(items)-1] += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		break
+	}
+
+	out.WriteString("<" + tag + ">\n")
+	for _, item := range items {
+		out.WriteString("<li>" + renderInline(item) + "</li>\n")
+	}
+	out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// renderInline renders emphasis, code spans and links within a block
+func renderInline(text string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_[]()#>-+.!", text[i+1]) >= 0:
+			out.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(text[i+1:], '`'); end >= 0 {
+				out.WriteString("<code>" + html.EscapeString(text[i+1:i+1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+
+		case strings.HasPrefix(text[i:], "**") || strings.HasPrefix(text[i:], "__"):
+			marker := text[i : i+2]
+			if end := strings.Index(text[i+2:], marker); end > 0 {
+				out.WriteString("<strong>" + renderInline(text[i+2:i+2+end]) + "</strong>")
+				i += end + 4
+				continue
+			}
+
+		case c == '*' || (c == '_' && (i == 0 || !isWordByte(text[i-1]))):
+			if end := strings.IndexByte(text[i+1:], c); end > 0 && text[i+1] != ' ' {
+				out.WriteString("<em>" + renderInline(text[i+1:i+1+end]) + "</em>")
+				i += end + 2
+				continue
+			}
+
+		case c == '[':
+			if label, url, n, ok := parseLink(text[i:]); ok {
+				if safe := safeURL(url); safe != "" {
+					out.WriteString(`<a href="` + html.EscapeString(safe) + `" rel="nofollow noopener">` +
+						renderInline(label) + "</a>")
+				} else {
+					out.WriteString(renderInline(label))
+				}
+				i += n
+				continue
+			}
+
+		case c == '\n':
+			out.WriteString("<br>\n")
+			i++
+			continue
+		}
+
+		out.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+
+	return out.String()
+}
+
+// parseLink parses [label](url) at the start of text and returns how many
+// bytes it spans
+func parseLink(text string) (label, url string, n int, ok bool) {
+	closeLabel := strings.Index(text, "](")
+	if closeLabel < 0 {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(text[closeLabel+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+
+	label = text[1:closeLabel]
+	url = strings.TrimSpace(text[closeLabel+2 : closeLabel+2+closeURL])
+	if strings.ContainsAny(url, " \n\t") {
+		return "", "", 0, false
+	}
+	return label, url, closeLabel + 3 + closeURL, true
+}
+
+// safeURL returns url if it uses an allowed scheme or is relative, and ""
+// otherwise, so javascript: and data: links are dropped. Protocol-relative
+// URLs, which browsers also accept as /\host, leave the site and are
+// dropped too.
+func safeURL(url string) string {
+	lower := strings.ToLower(url)
+	if strings.HasPrefix(lower, "//") || strings.HasPrefix(lower, `/\`) {
+		return ""
+	}
+	for _, prefix := range []string{"http://", "https://", "mailto:", "/", "#"} {
+		if strings.HasPrefix(lower, prefix) {
+			return url
+		}
+	}
+	return ""
+}
+
+// isWordByte reports whether b is an ASCII letter or digit
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
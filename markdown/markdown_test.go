@@ -0,0 +1,121 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		// Blocks
+		{"paragraph", "Hello\nworld", "<p>Hello<br>\nworld</p>\n"},
+		{"heading", "## Part *two* ##", "<h2>Part <em>two</em></h2>\n"},
+		{"quote", "> Quoted\n> text", "<blockquote>\n<p>Quoted<br>\ntext</p>\n</blockquote>\n"},
+		{"unordered list", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"},
+		{"ordered list", "1. one\n2. two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{"rule", "---", "<hr>\n"},
+		{"code block", "```\nif a < b {}\n```", "<pre><code>if a &lt; b {}</code></pre>\n"},
+
+		// Inline
+		{"strong and emphasis", "**bold** and *em* and _em_", "<p><strong>bold</strong> and <em>em</em> and <em>em</em></p>\n"},
+		{"nested emphasis", "**bold *and em* too**", "<p><strong>bold <em>and em</em> too</strong></p>\n"},
+		{"code span", "`<b>`", "<p><code>&lt;b&gt;</code></p>\n"},
+		{"escaped marker", `\*not em\*`, "<p>*not em*</p>\n"},
+		{"snake case", "snake_case_name", "<p>snake_case_name</p>\n"},
+
+		// Links
+		{"https link", "[site](https://example.com)",
+			`<p><a href="https://example.com" rel="nofollow noopener">site</a></p>` + "\n"},
+		{"relative links", "[book](/books/1) [top](#top)",
+			`<p><a href="/books/1" rel="nofollow noopener">book</a> <a href="#top" rel="nofollow noopener">top</a></p>` + "\n"},
+		{"mailto link", "[mail](mailto:me@example.com)",
+			`<p><a href="mailto:me@example.com" rel="nofollow noopener">mail</a></p>` + "\n"},
+		{"mixed case allowed scheme", "[site](HTTPS://EXAMPLE.COM)",
+			`<p><a href="HTTPS://EXAMPLE.COM" rel="nofollow noopener">site</a></p>` + "\n"},
+		{"nested emphasis in label", "[**bold** and *em* `code`](https://example.com)",
+			`<p><a href="https://example.com" rel="nofollow noopener"><strong>bold</strong> and <em>em</em> <code>code</code></a></p>` + "\n"},
+		{"link with spaces is text", "[a](https://example.com/a b)", "<p>[a](https://example.com/a b)</p>\n"},
+
+		// Unsafe input
+		{"javascript link", "[click](javascript:alert(1))", "<p>click)</p>\n"},
+		{"mixed case javascript link", "[click](JaVaScRiPt:alert(1))", "<p>click)</p>\n"},
+		{"data link", "[img](data:text/html;base64,PHNjcmlwdD4=)", "<p>img</p>\n"},
+		{"mixed case data link", "[img](DaTa:text/html,hi)", "<p>img</p>\n"},
+		{"vbscript link", "[x](vbscript:msgbox)", "<p>x</p>\n"},
+		{"protocol-relative link", "[x](//evil.example/login)", "<p>x</p>\n"},
+		{"backslash protocol-relative link", `[x](/\evil.example/login)`, "<p>x</p>\n"},
+		{"unsafe link keeps label markup", "[**x**](javascript:void)", "<p><strong>x</strong></p>\n"},
+		{"raw script", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"raw script in heading", "# <img src=x onerror=alert(1)>", "<h1>&lt;img src=x onerror=alert(1)&gt;</h1>\n"},
+		{"raw script in emphasis", "*<script>*", "<p><em>&lt;script&gt;</em></p>\n"},
+		{"raw script in label", "[<script>](https://example.com)",
+			`<p><a href="https://example.com" rel="nofollow noopener">&lt;script&gt;</a></p>` + "\n"},
+		{"double quote in URL", `[x](https://example.com/"onmouseover="alert(1))`,
+			`<p><a href="https://example.com/&#34;onmouseover=&#34;alert(1" rel="nofollow noopener">x</a>)</p>` + "\n"},
+		{"single quote in URL", `[x](https://example.com/'><script>)`,
+			`<p><a href="https://example.com/&#39;&gt;&lt;script&gt;" rel="nofollow noopener">x</a></p>` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToHTML(tt.src); got != tt.want {
+				t.Errorf("ToHTML(%q)\n got %q\nwant %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestToHTMLNeverEmitsUnsafeMarkup checks properties that must hold for
+// any input: no tags besides the ones the renderer writes, so raw HTML
+// and its event handlers stay text, and no href with a scheme outside
+// the allowed ones or pointing at another host
+func TestToHTMLNeverEmitsUnsafeMarkup(t *testing.T) {
+	inputs := []string{
+		"<script>alert(1)</script>",
+		"<SCRIPT SRC=//evil.example/x.js></SCRIPT>",
+		"<iframe src=javascript:alert(1)>",
+		"[x](javascript:alert(1))",
+		"[x](JAVASCRIPT:alert(1))",
+		"[x](  javascript:alert(1))",
+		"[x](data:text/html,<script>alert(1)</script>)",
+		"[x](Data:image/svg+xml,<svg onload=alert(1)>)",
+		"[x](https://example.com\"><script>alert(1)</script>)",
+		"[[x](javascript:alert(1))](https://example.com)",
+		"**[x](javascript:alert(1))**",
+		"> [x](javascript:alert(1))",
+		"- [x](data:,hi)",
+		"[x](//evil.example)",
+		"[x](/\\evil.example)",
+		"`<script>` <b onclick=\"x\">",
+	}
+
+	allowedTags := map[string]bool{
+		"p": true, "br": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"blockquote": true, "ul": true, "ol": true, "li": true, "pre": true, "code": true, "hr": true,
+		"strong": true, "em": true, "a": true,
+	}
+	tag := regexp.MustCompile(`<(/?)([a-zA-Z0-9]+)`)
+	href := regexp.MustCompile(`href="([^"]*)"`)
+
+	for _, src := range inputs {
+		got := ToHTML(src)
+		for _, m := range tag.FindAllStringSubmatch(got, -1) {
+			if !allowedTags[m[2]] {
+				t.Errorf("ToHTML(%q) = %q contains tag <%s%s>", src, got, m[1], m[2])
+			}
+		}
+		for _, m := range href.FindAllStringSubmatch(got, -1) {
+			url := strings.ToLower(m[1])
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") &&
+				!strings.HasPrefix(url, "mailto:") && !strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "#") ||
+				strings.HasPrefix(url, "//") || strings.HasPrefix(url, `/\`) {
+				t.Errorf("ToHTML(%q) = %q links to %q", src, got, m[1])
+			}
+		}
+	}
+}
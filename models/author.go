@@ -0,0 +1,25 @@
+package models
+
+// Author is a person credited on one or more books
+type Author struct {
+	ID        int
+	Name      string
+	BookCount int
+}
+
+// AuthorRole is how an author contributed to a book
+type AuthorRole string
+
+const (
+	RoleAuthor     AuthorRole = "author"
+	RoleTranslator AuthorRole = "translator"
+	RoleEditor     AuthorRole = "editor"
+)
+
+// BookAuthor credits an author on a book. Either AuthorID or Name is
+// enough when saving; the store links names to existing authors.
+type BookAuthor struct {
+	AuthorID int
+	Name     string
+	Role     AuthorRole
+}
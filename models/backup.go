@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Backup is a snapshot of the database. SchemaVersion is the last
+// migration applied to it.
+type Backup struct {
+	Name          string
+	Size          int64
+	SchemaVersion int
+	CreatedAt     time.Time
+}
+
+// BackupResult is a new snapshot and the names of the old ones the
+// retention policy removed
+type BackupResult struct {
+	Backup
+	Pruned []string
+}
@@ -12,6 +12,55 @@ type Book struct {
 	Notes     string
 	StartDate time.Time
 	EndDate   *time.Time
+	Version   int
+	PageCount int
+
+	// Edition details. ISBN is stored as ISBN-13 without separators, and
+	// audiobooks record DurationMinutes instead of PageCount.
+	ISBN            string
+	Publisher       string
+	PublicationYear int
+	Language        string
+	Format          BookFormat
+	DurationMinutes int
+
+	// WorkID groups the editions of one work. It is the ID of the book
+	// that was saved first; 0 on create starts a new work.
+	WorkID int
+
+	// SeriesID is the series the book belongs to, if any, and
+	// SeriesPosition its place in the reading order; decimals such as 1.5
+	// fit novellas between numbered books
+	SeriesID       *int
+	SeriesPosition *float64
+
+	// SeriesName is the name of the series; it is computed, never saved
+	SeriesName string
+
+	// Rating is the overall rating in half stars from 0.5 to 5; nil if unrated
+	Rating *float64
+
+	// Authors are the normalized credits behind Author, which is kept as
+	// the display string. Saving a book without Authors derives them from it.
+	Authors []BookAuthor
+
+	// Tags label the book; a nil slice leaves existing tags alone on update
+	Tags []string
+
+	// Sessions summarizes time spent reading; it is computed, never saved
+	Sessions SessionStats
+
+	// Readings are the read-throughs of the book, oldest first. Status
+	// changes open and close them; they are never saved through the book.
+	Readings []Reading
+
+	// Cover describes the uploaded cover image, if any; it is computed,
+	// never saved through the book
+	Cover *Cover
+
+	// Loan is the loan the book is currently out on, if any; it is
+	// computed, never saved
+	Loan *Loan
 }
 
 // BookStatus represents the reading status of a book
@@ -23,3 +72,13 @@ const (
 	StatusFinished  BookStatus = "finished"
 	StatusAbandoned BookStatus = "abandoned"
 )
+
+// BookFormat is the physical or digital form of an edition
+type BookFormat string
+
+const (
+	FormatHardcover BookFormat = "hardcover"
+	FormatPaperback BookFormat = "paperback"
+	FormatEbook     BookFormat = "ebook"
+	FormatAudiobook BookFormat = "audiobook"
+)
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Cover describes the cover image of a book. The original upload is
+// served as is; thumbnails are JPEG.
+type Cover struct {
+	ContentType string
+	Width       int
+	Height      int
+
+	// Size is the size of the original in bytes
+	Size int64
+
+	// Hash identifies the cover's files and changes whenever they do
+	Hash      string
+	UpdatedAt time.Time
+}
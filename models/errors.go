@@ -0,0 +1,27 @@
+package models
+
+import "errors"
+
+// Sentinel errors shared by the store and the HTTP handlers.
+// Wrap them with fmt.Errorf("...: %w", err) to add context.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("version mismatch")
+	ErrUnavailable        = errors.New("service unavailable")
+)
+
+// ValidationError reports input that breaks a domain rule
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// NewValidationError creates a validation error for the given field
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Field: field, Message: message}
+}
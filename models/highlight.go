@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// HighlightColor is the marker color of a highlight
+type HighlightColor string
+
+const (
+	ColorYellow HighlightColor = "yellow"
+	ColorGreen  HighlightColor = "green"
+	ColorBlue   HighlightColor = "blue"
+	ColorPink   HighlightColor = "pink"
+	ColorPurple HighlightColor = "purple"
+)
+
+// Highlight is a passage quoted from a book, found by Page or, for
+// e-books, by Location
+type Highlight struct {
+	ID        int
+	BookID    int
+	Text      string
+	Page      *int
+	Location  string
+	Comment   string
+	Color     HighlightColor
+	CreatedAt time.Time
+}
+
+// HighlightResult is a highlight together with the book it comes from.
+// Search results also carry an HTML-escaped Snippet of the text with
+// matches wrapped in <mark> tags and their Rank.
+type HighlightResult struct {
+	Highlight  Highlight
+	BookTitle  string
+	BookAuthor string
+	Snippet    string
+	Rank       float64
+}
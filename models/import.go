@@ -0,0 +1,63 @@
+package models
+
+// ImportAction is what an import did, or would do, with one row
+type ImportAction string
+
+const (
+	ImportCreated  ImportAction = "created"
+	ImportUpdated  ImportAction = "updated"
+	ImportSkipped  ImportAction = "skipped"
+	ImportRejected ImportAction = "rejected"
+)
+
+// ImportRow is a book read from line Line of an import file. A non-zero
+// Book.ID updates that book, based on Book.Version; otherwise the row
+// creates a new one. A non-empty SeriesName puts the book in the series
+// with that name, which is created when the library has none.
+//
+// A non-zero WorkKey is the work ID the import file gave the book, which
+// may come from another library: the first row with a key keeps
+// Book.WorkID, and later rows with the same key join the work that row
+// ended up in.
+type ImportRow struct {
+	Line       int
+	Book       Book
+	SeriesName string
+	WorkKey    int
+}
+
+// ImportResult reports the outcome of one row. BookID is 0 for rejected
+// rows and, on a dry run, for books that would be created.
+type ImportResult struct {
+	Line   int
+	Action ImportAction
+	BookID int
+	Title  string
+	Error  string
+}
+
+// ImportReport sums up an import. Nothing is saved when it is a dry run or
+// when any row was rejected.
+type ImportReport struct {
+	DryRun   bool
+	Created  int
+	Updated  int
+	Skipped  int
+	Rejected int
+	Rows     []ImportResult
+}
+
+// Add records the outcome of a row
+func (r *ImportReport) Add(result ImportResult) {
+	switch result.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportRejected:
+		r.Rejected++
+	}
+	r.Rows = append(r.Rows, result)
+}
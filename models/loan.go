@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Loan records a book lent to someone. ReturnedDate is nil while the
+// book is still out.
+type Loan struct {
+	ID           int
+	BookID       int
+	Borrower     string
+	Contact      string
+	LentDate     time.Time
+	DueDate      *time.Time
+	ReturnedDate *time.Time
+}
+
+// LoanListing is a loan together with the book that was lent
+type LoanListing struct {
+	Loan       Loan
+	BookTitle  string
+	BookAuthor string
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ReadingProgress is one logged position in a book. At least one of
+// Page, Percent or Location (e.g. an e-reader location) is set.
+type ReadingProgress struct {
+	ID       int
+	BookID   int
+	Page     *int
+	Percent  *float64
+	Location string
+	LoggedAt time.Time
+}
+
+// ProgressUpdate is the outcome of logging progress. SuggestFinish is set
+// when the reader reached the end of a book that isn't marked finished.
+type ProgressUpdate struct {
+	Progress      ReadingProgress
+	Book          Book
+	StatusChanged bool
+	SuggestFinish bool
+}
@@ -0,0 +1,61 @@
+package models
+
+import "github.com/favxlaw/filterql"
+
+// BookFilter describes which books to list, in what order and which page
+type BookFilter struct {
+	Status   string
+	Category string
+
+	// Tags keeps books carrying every listed tag, or any of them with MatchAnyTag
+	Tags        []string
+	MatchAnyTag bool
+
+	// ISBN keeps the edition with this normalized ISBN-13
+	ISBN string
+
+	// SeriesID keeps the books of one series
+	SeriesID int
+
+	// MinRating keeps books rated at least this much
+	MinRating *float64
+
+	// OnLoan keeps books that are lent out (true) or at home (false)
+	OnLoan *bool
+
+	// Where is an optional parsed filter expression ANDed with the above
+	Where filterql.Expr
+
+	// Sort lists the sort keys in priority order; empty means newest first
+	Sort []filterql.SortField
+
+	// Limit caps the number of books returned; After resumes a previous page
+	Limit int
+	After *Cursor
+
+	// CountTotal asks the store to also count every matching book
+	CountTotal bool
+}
+
+// Cursor marks the last book of a page in keyset pagination. Values hold
+// that book's sort keys (ending with its ID) so the next page can seek past it.
+type Cursor struct {
+	SortBy string
+	Values []string
+}
+
+// BookPage is one page of books plus where to continue from
+type BookPage struct {
+	Books []Book
+	Next  *Cursor
+	Total *int
+}
+
+// SearchResult is a book matched by full-text search. Snippet is an
+// HTML-escaped excerpt of the book's notes with matches wrapped in <mark>
+// tags.
+type SearchResult struct {
+	Book    Book
+	Snippet string
+	Rank    float64
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ReadingOutcome records how a read-through ended
+type ReadingOutcome string
+
+const (
+	OutcomeFinished  ReadingOutcome = "finished"
+	OutcomeAbandoned ReadingOutcome = "abandoned"
+)
+
+// Reading is one read-through of a book. EndDate is nil and Outcome is
+// empty while the read-through is in progress.
+type Reading struct {
+	ID        int
+	BookID    int
+	StartDate time.Time
+	EndDate   *time.Time
+	Outcome   ReadingOutcome
+	Rating    *float64
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Review is a Markdown write-up of a book. ReadingID ties it to one
+// read-through; nil means it is about the book as a whole.
+type Review struct {
+	ID        int
+	BookID    int
+	ReadingID *int
+	Body      string
+	Spoiler   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
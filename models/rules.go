@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/isbn"
+)
+
+// maxTagLength caps the length of a single tag name
+const maxTagLength = 64
+
+// languageCode matches BCP 47 style language tags such as en or pt-br
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// IsLanguageCode reports whether code is a lowercase language tag that
+// ValidateBook accepts
+func IsLanguageCode(code string) bool {
+	return languageCode.MatchString(code)
+}
+
+// ApplyEndDate sets EndDate from the status transition: finishing or
+// abandoning stamps it once, going back to reading/to_read clears it.
+// EndDate only describes the current read-through; earlier ones are kept
+// in the book's Readings.
+func ApplyEndDate(updatedBook *Book, existingBook *Book) {
+	if (updatedBook.Status == StatusFinished || updatedBook.Status == StatusAbandoned) &&
+		existingBook.EndDate == nil {
+		now := time.Now()
+		updatedBook.EndDate = &now
+	} else if updatedBook.Status == StatusReading || updatedBook.Status == StatusToRead {
+		updatedBook.EndDate = nil
+	} else {
+		updatedBook.EndDate = existingBook.EndDate
+	}
+}
+
+// ApplyAuthorEdit re-derives the credits from Author when an update
+// changes only the display string. Clients that edit Author in a book
+// they read send its old Authors back unchanged, and keeping those would
+// silently discard the edit.
+func ApplyAuthorEdit(updatedBook *Book, existingBook *Book) {
+	if updatedBook.Author != existingBook.Author && sameCreditNames(updatedBook.Authors, existingBook.Authors) {
+		updatedBook.Authors = nil
+	}
+}
+
+// sameCreditNames reports whether two lists credit the same names in the
+// same roles, whether or not their author IDs are resolved
+func sameCreditNames(a, b []BookAuthor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		roleA, roleB := a[i].Role, b[i].Role
+		if roleA == "" {
+			roleA = RoleAuthor
+		}
+		if roleB == "" {
+			roleB = RoleAuthor
+		}
+		if roleA != roleB || !strings.EqualFold(strings.TrimSpace(a[i].Name), strings.TrimSpace(b[i].Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateBook validates book data and normalizes the edition fields in
+// place: ISBNs become ISBN-13 without separators and languages lowercase
+func ValidateBook(book *Book) error {
+	if book.Title == "" {
+		return NewValidationError("Title", "title is required")
+	}
+
+	if book.Author == "" && len(book.Authors) == 0 {
+		return NewValidationError("Author", "author is required")
+	}
+
+	for _, credit := range book.Authors {
+		if credit.AuthorID == 0 && strings.TrimSpace(credit.Name) == "" {
+			return NewValidationError("Authors", "each author needs an AuthorID or a Name")
+		}
+
+		switch credit.Role {
+		case "", RoleAuthor, RoleTranslator, RoleEditor:
+		default:
+			return NewValidationError("Authors", "author role must be one of: author, translator, editor")
+		}
+	}
+
+	if book.PageCount < 0 {
+		return NewValidationError("PageCount", "page count cannot be negative")
+	}
+
+	if err := validateEdition(book); err != nil {
+		return err
+	}
+
+	if book.SeriesPosition != nil && book.SeriesID == nil {
+		return NewValidationError("SeriesPosition", "a series position needs a SeriesID")
+	}
+	if book.SeriesPosition != nil && *book.SeriesPosition < 0 {
+		return NewValidationError("SeriesPosition", "series position cannot be negative")
+	}
+
+	if err := ValidateTags(book.Tags); err != nil {
+		return err
+	}
+
+	if err := ValidateRating("Rating", book.Rating); err != nil {
+		return err
+	}
+
+	if book.Status != "" {
+		validStatuses := []BookStatus{
+			StatusToRead,
+			StatusReading,
+			StatusFinished,
+			StatusAbandoned,
+		}
+
+		isValid := false
+		for _, validStatus := range validStatuses {
+			if book.Status == validStatus {
+				isValid = true
+				break
+			}
+		}
+
+		if !isValid {
+			return NewValidationError("Status", "status must be one of: to_read, reading, finished, abandoned")
+		}
+	}
+
+	return nil
+}
+
+// validateEdition checks and normalizes the ISBN, language, year and
+// format of a book. Audiobooks are measured in minutes, everything else
+// in pages.
+func validateEdition(book *Book) error {
+	if strings.TrimSpace(book.ISBN) != "" {
+		normalized, err := isbn.Normalize(book.ISBN)
+		if err != nil {
+			return NewValidationError("ISBN", err.Error())
+		}
+		book.ISBN = normalized
+	} else {
+		book.ISBN = ""
+	}
+
+	book.Publisher = strings.TrimSpace(book.Publisher)
+	book.Language = strings.ToLower(strings.TrimSpace(book.Language))
+	if book.Language != "" && !languageCode.MatchString(book.Language) {
+		return NewValidationError("Language", "language must be a code like en, pt-br or fil")
+	}
+
+	if book.PublicationYear < 0 || book.PublicationYear > time.Now().Year()+1 {
+		return NewValidationError("PublicationYear", "publication year must be a past year")
+	}
+
+	if book.DurationMinutes < 0 {
+		return NewValidationError("DurationMinutes", "duration cannot be negative")
+	}
+
+	switch book.Format {
+	case "", FormatHardcover, FormatPaperback, FormatEbook:
+		if book.DurationMinutes != 0 {
+			return NewValidationError("DurationMinutes", "only audiobooks have a duration")
+		}
+	case FormatAudiobook:
+		if book.PageCount != 0 {
+			return NewValidationError("PageCount", "audiobooks have a duration instead of a page count")
+		}
+	default:
+		return NewValidationError("Format", "format must be one of: hardcover, paperback, ebook, audiobook")
+	}
+
+	if book.WorkID < 0 {
+		return NewValidationError("WorkID", "work ID cannot be negative")
+	}
+
+	return nil
+}
+
+// ValidateTags checks tag names are present and reasonably short
+func ValidateTags(tags []string) error {
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			return NewValidationError("Tags", "tags cannot be empty")
+		}
+		// ; separates tags in CSV imports and exports
+		if strings.Contains(tag, ";") {
+			return NewValidationError("Tags", "tags cannot contain ;")
+		}
+		if len(tag) > maxTagLength {
+			return NewValidationError("Tags", fmt.Sprintf("tags must be at most %d characters", maxTagLength))
+		}
+	}
+	return nil
+}
+
+// ValidateRating checks a rating is in half stars between 0.5 and 5
+func ValidateRating(field string, rating *float64) error {
+	if rating == nil {
+		return nil
+	}
+	if *rating < 0.5 || *rating > 5 || *rating*2 != math.Trunc(*rating*2) {
+		return NewValidationError(field, "rating must be between 0.5 and 5 in steps of 0.5")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package models
+
+// Series is an ordered run of books, such as a trilogy. Books join a
+// series through their SeriesID and SeriesPosition.
+type Series struct {
+	ID          int
+	Name        string
+	Description string
+	BookCount   int
+}
+
+// SeriesDetail is a series with its books in reading order
+type SeriesDetail struct {
+	Series
+	Books []Book
+}
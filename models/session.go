@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ReadingSession is a stretch of time spent reading a book. EndedAt is
+// nil while the session is still running.
+type ReadingSession struct {
+	ID              int
+	BookID          int
+	Reader          string
+	StartedAt       time.Time
+	EndedAt         *time.Time
+	DurationSeconds int64
+	PagesRead       int
+	Note            string
+}
+
+// SessionStats aggregates the finished reading sessions of a book
+type SessionStats struct {
+	Count        int
+	TotalSeconds int64
+	PagesRead    int
+	LastReadAt   *time.Time
+}
@@ -0,0 +1,25 @@
+package models
+
+// ShelfVisibility controls who may see a shelf
+type ShelfVisibility string
+
+const (
+	VisibilityPrivate ShelfVisibility = "private"
+	VisibilityPublic  ShelfVisibility = "public"
+)
+
+// Shelf is a hand-curated list of books. A book can sit on any number of
+// shelves, at a position the user chooses on each.
+type Shelf struct {
+	ID          int
+	Name        string
+	Description string
+	Visibility  ShelfVisibility
+	BookCount   int
+}
+
+// ShelfBook is a book on a shelf; positions start at 1
+type ShelfBook struct {
+	Position int
+	Book     Book
+}
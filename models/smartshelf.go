@@ -0,0 +1,19 @@
+package models
+
+// SmartShelf is a saved query over the library. Its books are whatever
+// matches when it is read, so it never needs maintaining.
+type SmartShelf struct {
+	ID          int
+	Name        string
+	Description string
+
+	// Filter and Sort use the syntax of the filter and sort parameters of
+	// GET /books; Tags and MatchAnyTag work like tag and tag_mode
+	Filter      string
+	Sort        string
+	Tags        []string
+	MatchAnyTag bool
+
+	// BookCount is how many books match right now
+	BookCount int
+}
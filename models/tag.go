@@ -0,0 +1,8 @@
+package models
+
+// Tag is a free-form label; a book can carry any number of them
+type Tag struct {
+	ID        int
+	Name      string
+	BookCount int
+}
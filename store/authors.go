@@ -0,0 +1,491 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/favxlaw/models"
+)
+
+// querier is the part of *sql.DB and *sql.Tx that store helpers need,
+// so the same helper can run inside or outside a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// errAuthorNotFound is returned when no author matches the requested ID
+var errAuthorNotFound = fmt.Errorf("author %w", models.ErrNotFound)
+
+// authorSeparator splits free-text credits such as "Hunt & Thomas" or
+// "Neil Gaiman and Terry Pratchett". Commas are left alone because
+// "Martin, Robert C." is a single name.
+var authorSeparator = regexp.MustCompile(`(?i)\s*(?:&|;|\band\b)\s*`)
+
+// splitAuthorNames derives individual names from a display string
+func splitAuthorNames(author string) []string {
+	var names []string
+	for _, name := range authorSeparator.Split(author, -1) {
+		if name = cleanAuthorName(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// cleanAuthorName trims and collapses whitespace in a name
+func cleanAuthorName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// authorNameKey is the deduplication key of a name: case, punctuation
+// and spacing differences ("Robert C. Martin", "robert c martin") collapse
+func authorNameKey(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer(".", " ", ",", " ", "'", "", "’", "").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// creditsFromDisplay turns a display string into author credits
+func creditsFromDisplay(author string) []models.BookAuthor {
+	var credits []models.BookAuthor
+	for _, name := range splitAuthorNames(author) {
+		credits = append(credits, models.BookAuthor{Name: name, Role: models.RoleAuthor})
+	}
+	return credits
+}
+
+// creditsDisplay builds the display string from resolved credits,
+// listing authors proper and falling back to everyone credited
+func creditsDisplay(credits []models.BookAuthor) string {
+	var names, all []string
+	for _, credit := range credits {
+		all = append(all, credit.Name)
+		if credit.Role == models.RoleAuthor {
+			names = append(names, credit.Name)
+		}
+	}
+	if len(names) == 0 {
+		names = all
+	}
+	return strings.Join(names, " & ")
+}
+
+// resolveCredits finds or creates the author behind each credit and
+// returns the credits with IDs and canonical names filled in. Repeated
+// author/role pairs are dropped.
+func resolveCredits(ctx context.Context, q querier, credits []models.BookAuthor) ([]models.BookAuthor, error) {
+	type creditKey struct {
+		authorID int
+		role     models.AuthorRole
+	}
+	seen := map[creditKey]bool{}
+
+	resolved := make([]models.BookAuthor, 0, len(credits))
+	for _, credit := range credits {
+		if credit.Role == "" {
+			credit.Role = models.RoleAuthor
+		}
+
+		var err error
+		if credit.AuthorID != 0 {
+			err = q.QueryRowContext(ctx, `SELECT name FROM authors WHERE id = ?`, credit.AuthorID).Scan(&credit.Name)
+			if err == sql.ErrNoRows {
+				return nil, models.NewValidationError("Authors", fmt.Sprintf("author %d does not exist", credit.AuthorID))
+			}
+		} else {
+			credit.AuthorID, credit.Name, err = ensureAuthor(ctx, q, credit.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key := creditKey{credit.AuthorID, credit.Role}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		resolved = append(resolved, credit)
+	}
+	return resolved, nil
+}
+
+// ensureAuthor returns the author matching name's key, creating it if needed
+func ensureAuthor(ctx context.Context, q querier, name string) (int, string, error) {
+	name = cleanAuthorName(name)
+	key := authorNameKey(name)
+	if key == "" {
+		return 0, "", models.NewValidationError("Authors", "author name is required")
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO authors (name, name_key) VALUES (?, ?) ON CONFLICT(name_key) DO NOTHING`,
+		name, key,
+	)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to save author: %w", translateError(err))
+	}
+
+	var id int
+	err = q.QueryRowContext(ctx, `SELECT id, name FROM authors WHERE name_key = ?`, key).Scan(&id, &name)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to find author: %w", translateError(err))
+	}
+	return id, name, nil
+}
+
+// linkCredits replaces a book's credits with the resolved ones, in order
+func linkCredits(ctx context.Context, q querier, bookID int, credits []models.BookAuthor) error {
+	_, err := q.ExecContext(ctx, `DELETE FROM book_authors WHERE book_id = ?`, bookID)
+	if err != nil {
+		return fmt.Errorf("failed to clear authors of book %d: %w", bookID, translateError(err))
+	}
+
+	for position, credit := range credits {
+		_, err := q.ExecContext(ctx,
+			`INSERT INTO book_authors (book_id, author_id, role, position) VALUES (?, ?, ?, ?)`,
+			bookID, credit.AuthorID, credit.Role, position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to link author %d to book %d: %w", credit.AuthorID, bookID, translateError(err))
+		}
+	}
+	return nil
+}
+
+// loadAuthors fills in the Authors of each book with one query
+func loadAuthors(ctx context.Context, q querier, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(books))
+	args := make([]interface{}, len(books))
+	for i := range books {
+		index[books[i].ID] = i
+		args[i] = books[i].ID
+		books[i].Authors = []models.BookAuthor{}
+	}
+
+	query := `
+		SELECT ba.book_id, a.id, a.name, ba.role
+		FROM book_authors ba
+		JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id IN (` + placeholders(len(books)) + `)
+		ORDER BY ba.book_id, ba.position
+	`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load authors: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var credit models.BookAuthor
+		if err := rows.Scan(&bookID, &credit.AuthorID, &credit.Name, &credit.Role); err != nil {
+			return fmt.Errorf("failed to scan author: %w", err)
+		}
+		i := index[bookID]
+		books[i].Authors = append(books[i].Authors, credit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read authors: %w", translateError(err))
+	}
+	return nil
+}
+
+// placeholders returns n comma-separated bind placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// refreshAuthorDisplay rebuilds the author display string of every book
+// credited to authorID after that author was renamed or merged
+func refreshAuthorDisplay(ctx context.Context, q querier, authorID int) error {
+	rows, err := q.QueryContext(ctx, `SELECT DISTINCT book_id FROM book_authors WHERE author_id = ?`, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to find books of author %d: %w", authorID, translateError(err))
+	}
+
+	var books []models.Book
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(&book.ID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan book: %w", err)
+		}
+		books = append(books, book)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read books: %w", translateError(err))
+	}
+
+	if err := loadAuthors(ctx, q, books); err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		_, err := q.ExecContext(ctx,
+			`UPDATE books SET author = ?, version = version + 1 WHERE id = ?`,
+			creditsDisplay(book.Authors), book.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update book %d: %w", book.ID, translateError(err))
+		}
+	}
+	return nil
+}
+
+// backfillAuthors credits every existing book from its author column
+func backfillAuthors(db *sql.DB) error {
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, author FROM books`)
+	if err != nil {
+		return err
+	}
+
+	var books []models.Book
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(&book.ID, &book.Author); err != nil {
+			rows.Close()
+			return err
+		}
+		books = append(books, book)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		credits, err := resolveCredits(ctx, tx, creditsFromDisplay(book.Author))
+		if err != nil {
+			return err
+		}
+		if err := linkCredits(ctx, tx, book.ID, credits); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListAuthors returns authors with their book counts, by name. A non-empty
+// q keeps only names containing it.
+func (s *SQLiteStore) ListAuthors(ctx context.Context, q string) ([]models.Author, error) {
+	query := `
+		SELECT a.id, a.name, COUNT(DISTINCT ba.book_id)
+		FROM authors a
+		LEFT JOIN book_authors ba ON ba.author_id = a.id
+		WHERE a.name LIKE ? ESCAPE '\'
+		GROUP BY a.id
+		ORDER BY a.name COLLATE NOCASE, a.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, "%"+escapeLike(q)+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authors: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	authors := []models.Author{}
+	for rows.Next() {
+		var author models.Author
+		if err := rows.Scan(&author.ID, &author.Name, &author.BookCount); err != nil {
+			return nil, fmt.Errorf("failed to scan author: %w", err)
+		}
+		authors = append(authors, author)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read authors: %w", translateError(err))
+	}
+	return authors, nil
+}
+
+// GetAuthor finds an author by ID
+func (s *SQLiteStore) GetAuthor(ctx context.Context, id int) (*models.Author, error) {
+	return getAuthor(ctx, s.db, id)
+}
+
+// getAuthor finds an author by ID using q
+func getAuthor(ctx context.Context, q querier, id int) (*models.Author, error) {
+	query := `
+		SELECT a.id, a.name, COUNT(DISTINCT ba.book_id)
+		FROM authors a
+		LEFT JOIN book_authors ba ON ba.author_id = a.id
+		WHERE a.id = ?
+		GROUP BY a.id
+	`
+
+	var author models.Author
+	err := q.QueryRowContext(ctx, query, id).Scan(&author.ID, &author.Name, &author.BookCount)
+	if err == sql.ErrNoRows {
+		return nil, errAuthorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author %d: %w", id, translateError(err))
+	}
+	return &author, nil
+}
+
+// GetAuthorBooks returns every book credited to an author, in any role
+func (s *SQLiteStore) GetAuthorBooks(ctx context.Context, id int) ([]models.Book, error) {
+	if _, err := s.GetAuthor(ctx, id); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + selectBookColumns("") + `
+		FROM books
+		WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = ?)
+		ORDER BY title COLLATE NOCASE, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query books of author %d: %w", id, translateError(err))
+	}
+	defer rows.Close()
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadBookDetails(ctx, s.db, books); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// RenameAuthor changes an author's name. Renaming onto another author's
+// name is a conflict; merge the two authors instead.
+func (s *SQLiteStore) RenameAuthor(ctx context.Context, id int, name string) (*models.Author, error) {
+	name = cleanAuthorName(name)
+	key := authorNameKey(name)
+	if key == "" {
+		return nil, models.NewValidationError("Name", "name is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	var existingID int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM authors WHERE name_key = ?`, key).Scan(&existingID)
+	if err == nil && existingID != id {
+		return nil, fmt.Errorf("author %d is already named %q, merge instead: %w", existingID, name, models.ErrConflict)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check author name: %w", translateError(err))
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE authors SET name = ?, name_key = ? WHERE id = ?`, name, key, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename author %d: %w", id, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to rename author %d: %w", id, translateError(err))
+	} else if n == 0 {
+		return nil, errAuthorNotFound
+	}
+
+	if err := refreshAuthorDisplay(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	author, err := getAuthor(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rename: %w", translateError(err))
+	}
+	return author, nil
+}
+
+// MergeAuthors folds duplicate authors into targetID: their credits move
+// to the target and the duplicates are deleted. An author listed more
+// than once is merged once.
+func (s *SQLiteStore) MergeAuthors(ctx context.Context, targetID int, sourceIDs []int) (*models.Author, error) {
+	seen := make(map[int]bool, len(sourceIDs))
+	unique := make([]int, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			return nil, models.NewValidationError("AuthorIDs", "cannot merge an author into itself")
+		}
+		if !seen[sourceID] {
+			seen[sourceID] = true
+			unique = append(unique, sourceID)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if _, err := getAuthor(ctx, tx, targetID); err != nil {
+		return nil, err
+	}
+
+	for _, sourceID := range unique {
+		if _, err := getAuthor(ctx, tx, sourceID); err != nil {
+			return nil, err
+		}
+
+		// Credits the target already has in the same role are dropped
+		_, err := tx.ExecContext(ctx,
+			`UPDATE OR IGNORE book_authors SET author_id = ? WHERE author_id = ?`,
+			targetID, sourceID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move credits of author %d: %w", sourceID, translateError(err))
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM book_authors WHERE author_id = ?`, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear credits of author %d: %w", sourceID, translateError(err))
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM authors WHERE id = ?`, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete author %d: %w", sourceID, translateError(err))
+		}
+	}
+
+	if err := refreshAuthorDisplay(ctx, tx, targetID); err != nil {
+		return nil, err
+	}
+
+	author, err := getAuthor(ctx, tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", translateError(err))
+	}
+	return author, nil
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+func TestMergeAuthorsSourceIDs(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	authorOf := func(title, author string) int {
+		book, err := s.Create(ctx, models.Book{Title: title, Author: author, Status: models.StatusToRead, StartDate: time.Now()})
+		if err != nil {
+			t.Fatalf("Create error: %v", err)
+		}
+		return book.Authors[0].AuthorID
+	}
+	target := authorOf("Dune", "Frank Herbert")
+	source := authorOf("Dune Messiah", "F. Herbert")
+
+	var validation *models.ValidationError
+	if _, err := s.MergeAuthors(ctx, target, []int{source, target}); !errors.As(err, &validation) {
+		t.Fatalf("MergeAuthors with the target among the sources: error = %v, want a validation error", err)
+	}
+	if _, err := s.GetAuthor(ctx, source); err != nil {
+		t.Fatalf("rejected merge deleted author %d: %v", source, err)
+	}
+
+	merged, err := s.MergeAuthors(ctx, target, []int{source, source})
+	if err != nil {
+		t.Fatalf("MergeAuthors with a repeated source: error = %v", err)
+	}
+	if merged.BookCount != 2 {
+		t.Errorf("merged author has %d books, want 2", merged.BookCount)
+	}
+	if _, err := s.GetAuthor(ctx, source); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAuthor(%d) after merge: error = %v, want not found", source, err)
+	}
+}
@@ -0,0 +1,278 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// Snapshots are named backup-<UTC time>.db, so sorting the names sorts
+// them by age
+const (
+	backupPrefix = "backup-"
+	backupSuffix = ".db"
+	backupLayout = "20060102-150405.000"
+)
+
+// Backup writes a consistent snapshot of the database into dir with
+// VACUUM INTO, which reads in a single transaction while other requests
+// carry on writing. The snapshot is written under a temporary name and
+// renamed once complete, so dir never holds half a snapshot. Afterwards
+// old snapshots are pruned down to keep days; see pruneBackups.
+func (s *SQLiteStore) Backup(ctx context.Context, dir string, keep int) (models.BackupResult, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return models.BackupResult{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	created := time.Now().UTC()
+	name := backupPrefix + created.Format(backupLayout) + backupSuffix
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		os.Remove(tmp)
+		return models.BackupResult{}, fmt.Errorf("failed to write snapshot: %w", translateError(err))
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return models.BackupResult{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	backup, err := inspectSnapshot(ctx, path)
+	if err != nil {
+		return models.BackupResult{}, err
+	}
+	backup.CreatedAt = created
+
+	pruned, err := pruneBackups(dir, keep, created)
+	if err != nil {
+		// The snapshot itself is fine; retention catches up next time
+		log.Printf("failed to prune backups in %s: %v", dir, err)
+	}
+	return models.BackupResult{Backup: backup, Pruned: pruned}, nil
+}
+
+// pruneBackups applies the retention policy to the snapshots in dir and
+// returns the names it removed. Every snapshot taken on the UTC day of now
+// is kept, and of each earlier day only the first one, which predates
+// whatever went wrong later that day. Only the keep most recent days with
+// snapshots are kept; keep 0 removes none. However often snapshots are
+// taken, they can't push out the earlier days.
+func pruneBackups(dir string, keep int, now time.Time) ([]string, error) {
+	if keep == 0 {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) {
+			names = append(names, name)
+		}
+	}
+
+	// Newest first, so each day's first snapshot is the last one seen
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	today := now.UTC().Format(backupLayout[:8])
+	var days []string
+	firstOfDay := map[string]string{}
+	for _, name := range names {
+		day := backupDay(name)
+		if len(days) == 0 || days[len(days)-1] != day {
+			days = append(days, day)
+		}
+		firstOfDay[day] = name
+	}
+	if len(days) > keep {
+		days = days[:keep]
+	}
+	kept := map[string]bool{}
+	for _, day := range days {
+		kept[day] = true
+	}
+
+	var pruned []string
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		day := backupDay(name)
+		if kept[day] && (day == today || firstOfDay[day] == name) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, name)
+	}
+	return pruned, nil
+}
+
+// backupDay is the UTC day a snapshot was taken, as the yyyymmdd part of
+// its name
+func backupDay(name string) string {
+	day := strings.TrimPrefix(name, backupPrefix)
+	if len(day) < 8 {
+		return day
+	}
+	return day[:8]
+}
+
+// inspectSnapshot opens a snapshot read-only, checks its integrity and
+// reads its schema version
+func inspectSnapshot(ctx context.Context, path string) (models.Backup, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return models.Backup{}, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return models.Backup{}, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer db.Close()
+
+	var check string
+	if err := db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&check); err != nil {
+		return models.Backup{}, fmt.Errorf("%s is not a database: %w", path, err)
+	}
+	if check != "ok" {
+		return models.Backup{}, fmt.Errorf("%s is corrupt: %s", path, check)
+	}
+
+	backup := models.Backup{Name: filepath.Base(path), Size: info.Size(), CreatedAt: info.ModTime().UTC()}
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&backup.SchemaVersion)
+	if err != nil || backup.SchemaVersion == 0 {
+		return models.Backup{}, fmt.Errorf("%s has no schema version; is it a backup of this database?", path)
+	}
+	return backup, nil
+}
+
+// Restore replaces the database at dbPath with a snapshot. The snapshot
+// must pass an integrity check and must not be newer than the last of
+// migrations; an older one is brought up to date when the database is
+// next opened. The replaced database is kept next to it as
+// <dbPath>.pre-restore-<UTC time>, which is returned; it is empty when
+// there was no database yet.
+//
+// The server must be stopped first. A journal next to the database means
+// it is mid-write or wasn't closed cleanly, and restoring is refused. The
+// database is locked exclusively while it is swapped out, which waits
+// for a running server to finish writing, but a server sitting idle
+// can't be detected: it would carry on with the replaced file.
+func Restore(ctx context.Context, dbPath, snapshot string) (models.Backup, string, error) {
+	backup, err := inspectSnapshot(ctx, snapshot)
+	if err != nil {
+		return models.Backup{}, "", err
+	}
+
+	latest := migrations[len(migrations)-1].Version
+	if backup.SchemaVersion > latest {
+		return backup, "", fmt.Errorf("snapshot has schema version %d but this build only knows migrations up to %d; restore it with a newer build",
+			backup.SchemaVersion, latest)
+	}
+
+	for _, suffix := range []string{"-journal", "-wal"} {
+		if _, err := os.Stat(dbPath + suffix); err == nil {
+			return backup, "", fmt.Errorf("%s%s exists; stop the server before restoring", dbPath, suffix)
+		}
+	}
+
+	// Copy next to the database first, so the final rename is atomic
+	tmp := dbPath + ".restore"
+	if err := copyFile(snapshot, tmp); err != nil {
+		os.Remove(tmp)
+		return backup, "", fmt.Errorf("failed to copy snapshot: %w", err)
+	}
+
+	unlock, err := lockDatabase(ctx, dbPath)
+	if err != nil {
+		os.Remove(tmp)
+		return backup, "", err
+	}
+	defer unlock()
+
+	previous := dbPath + ".pre-restore-" + time.Now().UTC().Format(backupLayout)
+	if err := os.Rename(dbPath, previous); errors.Is(err, os.ErrNotExist) {
+		previous = ""
+	} else if err != nil {
+		os.Remove(tmp)
+		return backup, "", fmt.Errorf("failed to move the current database aside: %w", err)
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		if previous != "" {
+			os.Rename(previous, dbPath)
+		}
+		os.Remove(tmp)
+		return backup, "", fmt.Errorf("failed to swap in snapshot: %w", err)
+	}
+	return backup, previous, nil
+}
+
+// lockDatabase takes an exclusive lock on the database at dbPath, if
+// there is one, and returns the function that releases it. Taking the
+// lock waits for other connections to finish their transactions.
+func lockDatabase(ctx context.Context, dbPath string) (func(), error) {
+	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
+		return func() {}, nil
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=rw&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	conn, err := db.Conn(ctx)
+	if err == nil {
+		_, err = conn.ExecContext(ctx, `BEGIN EXCLUSIVE`)
+		if err != nil {
+			conn.Close()
+		}
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to lock %s; stop the server before restoring: %w", dbPath, translateError(err))
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), `ROLLBACK`)
+		conn.Close()
+		db.Close()
+	}, nil
+}
+
+// copyFile copies src to a new file dst and syncs it to disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
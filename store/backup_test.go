@@ -0,0 +1,99 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPruneBackups(t *testing.T) {
+	now := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+	files := []string{
+		"backup-20250301-020000.000.db",
+		"backup-20250308-020000.000.db",
+		"backup-20250308-140000.000.db",
+		"backup-20250309-020000.000.db",
+		"backup-20250309-120000.000.db",
+		"backup-20250309-235959.000.db",
+		"backup-20250310-020000.000.db",
+		"backup-20250310-120000.000.db",
+		"backup-20250310-120001.000.db",
+		"backup-20250310-120002.000.db",
+		"backup-20250310-120003.000.db",
+		"notes.txt",
+		"backup-20250309-020000.000.db.tmp",
+	}
+
+	tests := []struct {
+		keep int
+		kept []string
+	}{
+		{0, files},
+		{1, []string{
+			"backup-20250310-020000.000.db",
+			"backup-20250310-120000.000.db",
+			"backup-20250310-120001.000.db",
+			"backup-20250310-120002.000.db",
+			"backup-20250310-120003.000.db",
+			"notes.txt",
+			"backup-20250309-020000.000.db.tmp",
+		}},
+		{3, []string{
+			"backup-20250308-020000.000.db",
+			"backup-20250309-020000.000.db",
+			"backup-20250310-020000.000.db",
+			"backup-20250310-120000.000.db",
+			"backup-20250310-120001.000.db",
+			"backup-20250310-120002.000.db",
+			"backup-20250310-120003.000.db",
+			"notes.txt",
+			"backup-20250309-020000.000.db.tmp",
+		}},
+		{30, []string{
+			"backup-20250301-020000.000.db",
+			"backup-20250308-020000.000.db",
+			"backup-20250309-020000.000.db",
+			"backup-20250310-020000.000.db",
+			"backup-20250310-120000.000.db",
+			"backup-20250310-120001.000.db",
+			"backup-20250310-120002.000.db",
+			"backup-20250310-120003.000.db",
+			"notes.txt",
+			"backup-20250309-020000.000.db.tmp",
+		}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for _, name := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pruned, err := pruneBackups(dir, tt.keep, now)
+		if err != nil {
+			t.Fatalf("pruneBackups(%d) error: %v", tt.keep, err)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Name())
+		}
+		want := append([]string{}, tt.kept...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("pruneBackups(%d) kept %q, want %q", tt.keep, got, want)
+		}
+		if len(pruned)+len(got) != len(files) {
+			t.Errorf("pruneBackups(%d) reported %d removed, but %d files are gone", tt.keep, len(pruned), len(files)-len(got))
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// errCoverNotFound is returned when a book has no cover, or no file of
+// the requested name
+var errCoverNotFound = fmt.Errorf("cover %w", models.ErrNotFound)
+
+// SaveCover stores the files of a book's cover, replacing any previous
+// cover. files maps file names to their contents; all of them are written
+// to the book's cover directory before it is swapped in, so readers never
+// see a half-written set.
+func (s *SQLiteStore) SaveCover(ctx context.Context, bookID int, cover models.Cover, files map[string][]byte) (models.Cover, error) {
+	if s.coverDir == "" {
+		return models.Cover{}, fmt.Errorf("covers are not configured: %w", models.ErrUnavailable)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Cover{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if _, err := getBook(ctx, tx, bookID); err != nil {
+		return models.Cover{}, err
+	}
+
+	staged, err := s.stageCoverFiles(bookID, files)
+	if err != nil {
+		return models.Cover{}, err
+	}
+	defer os.RemoveAll(staged)
+
+	cover.Hash = hashCoverFiles(files)
+	cover.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO covers (book_id, content_type, width, height, size, hash, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(book_id) DO UPDATE SET
+			content_type = excluded.content_type, width = excluded.width, height = excluded.height,
+			size = excluded.size, hash = excluded.hash, updated_at = excluded.updated_at
+	`, bookID, cover.ContentType, cover.Width, cover.Height, cover.Size, cover.Hash, cover.UpdatedAt.Format(time.RFC3339))
+	if err != nil {
+		return models.Cover{}, fmt.Errorf("failed to save cover of book %d: %w", bookID, translateError(err))
+	}
+
+	if err := touchBook(ctx, tx, bookID); err != nil {
+		return models.Cover{}, err
+	}
+
+	// Swap the staged files in, keeping the old ones until the commit
+	dir := s.coverPath(bookID)
+	previous := dir + ".old"
+	os.RemoveAll(previous)
+	hadPrevious := os.Rename(dir, previous) == nil
+
+	restore := func() {
+		os.RemoveAll(dir)
+		if hadPrevious {
+			os.Rename(previous, dir)
+		}
+	}
+
+	if err := os.Rename(staged, dir); err != nil {
+		restore()
+		return models.Cover{}, fmt.Errorf("failed to store cover of book %d: %w", bookID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		restore()
+		return models.Cover{}, fmt.Errorf("failed to commit cover of book %d: %w", bookID, translateError(err))
+	}
+
+	os.RemoveAll(previous)
+	return cover, nil
+}
+
+// stageCoverFiles writes files into a fresh temporary directory next to
+// the book's cover directory and returns its path
+func (s *SQLiteStore) stageCoverFiles(bookID int, files map[string][]byte) (string, error) {
+	if err := os.MkdirAll(s.coverDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create cover directory: %w", err)
+	}
+
+	staged, err := os.MkdirTemp(s.coverDir, strconv.Itoa(bookID)+"-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to stage cover of book %d: %w", bookID, err)
+	}
+	if err := os.Chmod(staged, 0o755); err != nil {
+		os.RemoveAll(staged)
+		return "", fmt.Errorf("failed to stage cover of book %d: %w", bookID, err)
+	}
+
+	for name, data := range files {
+		if filepath.Base(name) != name {
+			os.RemoveAll(staged)
+			return "", fmt.Errorf("invalid cover file name %q", name)
+		}
+		if err := os.WriteFile(filepath.Join(staged, name), data, 0o644); err != nil {
+			os.RemoveAll(staged)
+			return "", fmt.Errorf("failed to write cover of book %d: %w", bookID, err)
+		}
+	}
+
+	return staged, nil
+}
+
+// hashCoverFiles hashes a cover's files, names included, into a hex
+// string that changes whenever any of them does
+func hashCoverFiles(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(files[name]))
+		hash.Write(files[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// backfillCoverHash hashes the files of a cover saved before covers had
+// a hash, and records it so this only happens once
+func (s *SQLiteStore) backfillCoverHash(ctx context.Context, bookID int) (string, error) {
+	dir := s.coverPath(bookID)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return "", errCoverNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read cover of book %d: %w", bookID, err)
+	}
+
+	files := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return "", fmt.Errorf("failed to read cover of book %d: %w", bookID, err)
+		}
+		files[entry.Name()] = data
+	}
+
+	hash := hashCoverFiles(files)
+	_, err = s.db.ExecContext(ctx, `UPDATE covers SET hash = ? WHERE book_id = ? AND hash = ''`, hash, bookID)
+	if err != nil {
+		return "", fmt.Errorf("failed to save cover hash of book %d: %w", bookID, translateError(err))
+	}
+	return hash, nil
+}
+
+// OpenCover opens one file of a book's cover. The caller closes it.
+func (s *SQLiteStore) OpenCover(ctx context.Context, bookID int, name string) (*os.File, models.Cover, error) {
+	var cover models.Cover
+	var updatedAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT content_type, width, height, size, hash, updated_at FROM covers WHERE book_id = ?`, bookID,
+	).Scan(&cover.ContentType, &cover.Width, &cover.Height, &cover.Size, &cover.Hash, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, cover, errCoverNotFound
+	}
+	if err != nil {
+		return nil, cover, fmt.Errorf("failed to get cover of book %d: %w", bookID, translateError(err))
+	}
+	cover.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+	if filepath.Base(name) != name {
+		return nil, cover, errCoverNotFound
+	}
+
+	if cover.Hash == "" {
+		if cover.Hash, err = s.backfillCoverHash(ctx, bookID); err != nil {
+			return nil, cover, err
+		}
+	}
+
+	file, err := os.Open(filepath.Join(s.coverPath(bookID), name))
+	if os.IsNotExist(err) {
+		return nil, cover, errCoverNotFound
+	}
+	if err != nil {
+		return nil, cover, fmt.Errorf("failed to open cover of book %d: %w", bookID, err)
+	}
+	return file, cover, nil
+}
+
+// DeleteCover removes a book's cover and its files
+func (s *SQLiteStore) DeleteCover(ctx context.Context, bookID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM covers WHERE book_id = ?`, bookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete cover of book %d: %w", bookID, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete cover of book %d: %w", bookID, translateError(err))
+	} else if n == 0 {
+		return errCoverNotFound
+	}
+
+	if err := touchBook(ctx, tx, bookID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cover of book %d: %w", bookID, translateError(err))
+	}
+
+	s.removeCoverFiles(bookID)
+	return nil
+}
+
+// removeCoverFiles deletes a book's cover directory. The database no
+// longer refers to it, so a failure only leaves stray files and is logged.
+func (s *SQLiteStore) removeCoverFiles(bookID int) {
+	if s.coverDir == "" {
+		return
+	}
+	if err := os.RemoveAll(s.coverPath(bookID)); err != nil {
+		log.Printf("failed to remove cover files of book %d: %v", bookID, err)
+	}
+}
+
+// coverPath is the directory holding a book's cover files
+func (s *SQLiteStore) coverPath(bookID int) string {
+	return filepath.Join(s.coverDir, strconv.Itoa(bookID))
+}
+
+// loadCovers fills in the cover of each book with one query
+func loadCovers(ctx context.Context, q querier, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(books))
+	args := make([]interface{}, len(books))
+	for i := range books {
+		index[books[i].ID] = i
+		args[i] = books[i].ID
+		books[i].Cover = nil
+	}
+
+	query := `
+		SELECT book_id, content_type, width, height, size, hash, updated_at
+		FROM covers
+		WHERE book_id IN (` + placeholders(len(books)) + `)
+	`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load covers: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var cover models.Cover
+		var updatedAt string
+		if err := rows.Scan(&bookID, &cover.ContentType, &cover.Width, &cover.Height, &cover.Size, &cover.Hash, &updatedAt); err != nil {
+			return fmt.Errorf("failed to scan cover: %w", err)
+		}
+		cover.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		books[index[bookID]].Cover = &cover
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read covers: %w", translateError(err))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/favxlaw/models"
+)
+
+// GetEditions returns every edition of the work a book belongs to,
+// including the book itself, oldest publication first
+func (s *SQLiteStore) GetEditions(ctx context.Context, bookID int) ([]models.Book, error) {
+	var workID int
+	err := s.db.QueryRowContext(ctx, `SELECT work_id FROM books WHERE id = ?`, bookID).Scan(&workID)
+	if err == sql.ErrNoRows {
+		return nil, errBookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get book %d: %w", bookID, translateError(err))
+	}
+
+	query := `
+		SELECT ` + selectBookColumns("") + `
+		FROM books
+		WHERE work_id = ?
+		ORDER BY publication_year = 0, publication_year, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, workID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query editions of book %d: %w", bookID, translateError(err))
+	}
+	defer rows.Close()
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadBookDetails(ctx, s.db, books); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// checkWork makes sure a book joins a work that exists. A book may
+// always stay in, or start, its own work.
+func checkWork(ctx context.Context, q querier, workID, bookID int) error {
+	if workID == 0 || workID == bookID {
+		return nil
+	}
+
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM books WHERE work_id = ?)`, workID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check work %d: %w", workID, translateError(err))
+	}
+	if !exists {
+		return models.NewValidationError("WorkID", fmt.Sprintf("work %d does not exist", workID))
+	}
+	return nil
+}
+
+// checkISBN reports a conflict when another book already has the ISBN
+func checkISBN(ctx context.Context, q querier, id int, isbn string) error {
+	if isbn == "" {
+		return nil
+	}
+
+	var existingID int
+	err := q.QueryRowContext(ctx, `SELECT id FROM books WHERE isbn = ?`, isbn).Scan(&existingID)
+	if err == nil && existingID != id {
+		return fmt.Errorf("book %d already has ISBN %s: %w", existingID, isbn, models.ErrConflict)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check ISBN: %w", translateError(err))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/favxlaw/models"
+	"github.com/mattn/go-sqlite3"
+)
+
+// errBookNotFound is returned when no book matches the requested ID
+var errBookNotFound = fmt.Errorf("book %w", models.ErrNotFound)
+
+// uniqueError is a unique constraint violation reported by SQLite. Its
+// message is fixed, since the driver's message names tables and columns, and
+// it ends up in responses; the driver error is still in the chain.
+type uniqueError struct {
+	err error
+}
+
+func (e *uniqueError) Error() string {
+	return "conflicts with an existing record"
+}
+
+func (e *uniqueError) Unwrap() []error {
+	return []error{models.ErrConflict, e.err}
+}
+
+// translateError maps driver errors onto the shared domain errors so
+// callers never need to know about SQLite
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", models.ErrUnavailable, err)
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrFull:
+			return fmt.Errorf("%w: %w", models.ErrUnavailable, err)
+		}
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return &uniqueError{err: err}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,455 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/filterql"
+	"github.com/favxlaw/models"
+)
+
+// columnKind decides how filter values are checked and compared
+type columnKind int
+
+const (
+	textColumn columnKind = iota
+	intColumn
+	numberColumn
+	dateColumn
+)
+
+// bookColumn is a field that filters and sorts may reference. expr is
+// the SQL used for it and value reads the same field from a book for cursors.
+type bookColumn struct {
+	expr  string
+	kind  columnKind
+	value func(models.Book) string
+}
+
+// bookColumns whitelists the fields of the filter language. Field names
+// never reach SQL directly; only the expressions below do.
+var bookColumns = map[string]bookColumn{
+	"id": {
+		expr:  "id",
+		kind:  intColumn,
+		value: func(b models.Book) string { return strconv.Itoa(b.ID) },
+	},
+	"title": {
+		expr:  "title",
+		kind:  textColumn,
+		value: func(b models.Book) string { return b.Title },
+	},
+	"author": {
+		expr:  "author",
+		kind:  textColumn,
+		value: func(b models.Book) string { return b.Author },
+	},
+	"status": {
+		expr:  "status",
+		kind:  textColumn,
+		value: func(b models.Book) string { return string(b.Status) },
+	},
+	"category": {
+		expr:  "COALESCE(category, '')",
+		kind:  textColumn,
+		value: func(b models.Book) string { return b.Category },
+	},
+	"notes": {
+		expr:  "COALESCE(notes, '')",
+		kind:  textColumn,
+		value: func(b models.Book) string { return b.Notes },
+	},
+	"start_date": {
+		expr:  "start_date",
+		kind:  dateColumn,
+		value: func(b models.Book) string { return b.StartDate.Format(time.RFC3339) },
+	},
+	"end_date": {
+		expr: "COALESCE(end_date, '')",
+		kind: dateColumn,
+		value: func(b models.Book) string {
+			if b.EndDate == nil {
+				return ""
+			}
+			return b.EndDate.Format(time.RFC3339)
+		},
+	},
+	"version": {
+		expr:  "version",
+		kind:  intColumn,
+		value: func(b models.Book) string { return strconv.Itoa(b.Version) },
+	},
+	"page_count": {
+		expr:  "page_count",
+		kind:  intColumn,
+		value: func(b models.Book) string { return strconv.Itoa(b.PageCount) },
+	},
+	"isbn": {
+		expr:  "isbn",
+		kind:  textColumn,
+		value: func(b models.Book) string { return b.ISBN },
+	},
+	"publisher": {
+		expr:  "publisher",
+		kind:  textColumn,
+		value: func(b models.Book) string { return b.Publisher },
+	},
+	"publication_year": {
+		expr:  "publication_year",
+		kind:  intColumn,
+		value: func(b models.Book) string { return strconv.Itoa(b.PublicationYear) },
+	},
+	"language": {
+		expr:  "language",
+		kind:  textColumn,
+		value: func(b models.Book) string { return b.Language },
+	},
+	"format": {
+		expr:  "format",
+		kind:  textColumn,
+		value: func(b models.Book) string { return string(b.Format) },
+	},
+	"duration_minutes": {
+		expr:  "duration_minutes",
+		kind:  intColumn,
+		value: func(b models.Book) string { return strconv.Itoa(b.DurationMinutes) },
+	},
+	"work_id": {
+		expr:  "work_id",
+		kind:  intColumn,
+		value: func(b models.Book) string { return strconv.Itoa(b.WorkID) },
+	},
+	"series_id": {
+		expr: "COALESCE(series_id, 0)",
+		kind: intColumn,
+		value: func(b models.Book) string {
+			if b.SeriesID == nil {
+				return "0"
+			}
+			return strconv.Itoa(*b.SeriesID)
+		},
+	},
+	// Unnumbered books compare as 0; see sortColumns for their order
+	"series_position": {
+		expr: "COALESCE(series_position, 0)",
+		kind: numberColumn,
+		value: func(b models.Book) string {
+			if b.SeriesPosition == nil {
+				return "0"
+			}
+			return strconv.FormatFloat(*b.SeriesPosition, 'f', -1, 64)
+		},
+	},
+	// Unrated books compare as 0, so they sort below every rated book
+	"rating": {
+		expr: "COALESCE(rating, 0)",
+		kind: numberColumn,
+		value: func(b models.Book) string {
+			if b.Rating == nil {
+				return "0"
+			}
+			return strconv.FormatFloat(*b.Rating, 'f', -1, 64)
+		},
+	},
+}
+
+// unnumberedPosition is the series position unnumbered books sort at.
+// Real positions never come near it.
+const unnumberedPosition = "1e308"
+
+// sortColumns override bookColumns for fields that sort differently than
+// they compare in filters. Unnumbered books sort after the numbered ones,
+// like in GET /series/{id}.
+var sortColumns = map[string]bookColumn{
+	"series_position": {
+		expr: "COALESCE(series_position, " + unnumberedPosition + ")",
+		kind: numberColumn,
+		value: func(b models.Book) string {
+			if b.SeriesPosition == nil {
+				return unnumberedPosition
+			}
+			return strconv.FormatFloat(*b.SeriesPosition, 'f', -1, 64)
+		},
+	},
+}
+
+// compileFilter turns a parsed filter into a parameterized SQL condition,
+// appending its bind values to args
+func compileFilter(expr filterql.Expr, args *[]interface{}) (string, error) {
+	switch node := expr.(type) {
+	case *filterql.Logical:
+		left, err := compileFilter(node.Left, args)
+		if err != nil {
+			return "", err
+		}
+		right, err := compileFilter(node.Right, args)
+		if err != nil {
+			return "", err
+		}
+		op := "AND"
+		if node.Op == "OR" {
+			op = "OR"
+		}
+		return "(" + left + " " + op + " " + right + ")", nil
+
+	case *filterql.Not:
+		inner, err := compileFilter(node.Expr, args)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+
+	case *filterql.Comparison:
+		return compileComparison(node, args)
+
+	default:
+		return "", fmt.Errorf("unsupported filter node %T", expr)
+	}
+}
+
+// compileComparison compiles a single field test
+func compileComparison(cmp *filterql.Comparison, args *[]interface{}) (string, error) {
+	column, ok := bookColumns[cmp.Field]
+	if !ok {
+		return "", &filterql.Error{Param: "filter", Pos: cmp.Pos, Token: cmp.Field, Message: "unknown field"}
+	}
+
+	if cmp.Op == filterql.OpContains {
+		if column.kind != textColumn {
+			return "", &filterql.Error{Param: "filter", Pos: cmp.Pos, Token: cmp.Field, Message: "operator :~ only applies to text fields"}
+		}
+		*args = append(*args, "%"+escapeLike(cmp.Values[0])+"%")
+		return column.expr + ` LIKE ? ESCAPE '\'`, nil
+	}
+
+	// Dates are compared by calendar day so 2025-01-01 matches any time that day
+	left, placeholder := column.expr, "?"
+	if column.kind == dateColumn {
+		left, placeholder = "date("+column.expr+")", "date(?)"
+	}
+
+	values := make([]interface{}, len(cmp.Values))
+	for i, raw := range cmp.Values {
+		value, err := columnValue(column, raw)
+		if err != nil {
+			return "", &filterql.Error{Param: "filter", Pos: valuePos(cmp, i), Token: raw, Message: err.Error()}
+		}
+		values[i] = value
+	}
+	*args = append(*args, values...)
+
+	switch cmp.Op {
+	case filterql.OpIn:
+		placeholders := strings.TrimSuffix(strings.Repeat(placeholder+", ", len(values)), ", ")
+		return left + " IN (" + placeholders + ")", nil
+	case filterql.OpEq:
+		return left + " = " + placeholder, nil
+	case filterql.OpNe:
+		return left + " != " + placeholder, nil
+	case filterql.OpGt, filterql.OpGe, filterql.OpLt, filterql.OpLe:
+		return left + " " + string(cmp.Op) + " " + placeholder, nil
+	default:
+		return "", &filterql.Error{Param: "filter", Pos: cmp.Pos, Token: string(cmp.Op), Message: "unsupported operator"}
+	}
+}
+
+// valuePos returns where the i-th value of a comparison starts, falling
+// back to the field for comparisons that were not parsed from text
+func valuePos(cmp *filterql.Comparison, i int) int {
+	if i < len(cmp.ValuePos) {
+		return cmp.ValuePos[i]
+	}
+	return cmp.Pos
+}
+
+// columnValue checks a raw filter value against the column's kind
+func columnValue(column bookColumn, raw string) (interface{}, error) {
+	switch column.kind {
+	case intColumn:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected a whole number")
+		}
+		return n, nil
+	case numberColumn:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number")
+		}
+		return f, nil
+	case dateColumn:
+		if _, err := time.Parse("2006-01-02", raw); err == nil {
+			return raw, nil
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t.Format(time.RFC3339), nil
+		}
+		if t, ok := relativeDate(raw, time.Now()); ok {
+			return t.Format("2006-01-02"), nil
+		}
+		return nil, fmt.Errorf("expected a date like 2025-01-31, today, year_start, month_start or -30d")
+	default:
+		return raw, nil
+	}
+}
+
+// relativeDate resolves dates relative to now: today, year_start,
+// month_start, or a number of days, weeks, months or years ago such as
+// -30d or -1y. They are resolved on every query, so saved filters like
+// end_date>=year_start stay current.
+func relativeDate(raw string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch raw {
+	case "today":
+		return today, true
+	case "year_start":
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), true
+	case "month_start":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), true
+	}
+
+	if len(raw) < 3 || raw[0] != '-' {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(raw[1 : len(raw)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+
+	switch raw[len(raw)-1] {
+	case 'd':
+		return today.AddDate(0, 0, -n), true
+	case 'w':
+		return today.AddDate(0, 0, -7*n), true
+	case 'm':
+		return today.AddDate(0, -n, 0), true
+	case 'y':
+		return today.AddDate(-n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// sortTerm is one resolved ORDER BY key
+type sortTerm struct {
+	column bookColumn
+	desc   bool
+}
+
+// resolveSort maps sort fields onto columns and appends id as a final
+// tiebreaker (in the direction of the last key) so every position is unique
+func resolveSort(fields []filterql.SortField) ([]sortTerm, error) {
+	if len(fields) == 0 {
+		return []sortTerm{{column: bookColumns["id"], desc: true}}, nil
+	}
+
+	terms := make([]sortTerm, 0, len(fields)+1)
+	hasID := false
+	for _, field := range fields {
+		column, ok := sortColumns[field.Field]
+		if !ok {
+			column, ok = bookColumns[field.Field]
+		}
+		if !ok {
+			return nil, &filterql.Error{Param: "sort", Pos: field.Pos, Token: field.Field, Message: "unknown field"}
+		}
+		if field.Field == "id" {
+			hasID = true
+		}
+		terms = append(terms, sortTerm{column: column, desc: field.Desc})
+	}
+
+	if !hasID {
+		terms = append(terms, sortTerm{column: bookColumns["id"], desc: fields[len(fields)-1].Desc})
+	}
+	return terms, nil
+}
+
+// sortKeyString is the canonical form of a sort, stored in cursors so a
+// cursor can't be replayed against a different ordering
+func sortKeyString(fields []filterql.SortField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field.Field
+		if field.Desc {
+			parts[i] = "-" + field.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// orderByClause renders the ORDER BY for resolved sort terms
+func orderByClause(terms []sortTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		direction := "ASC"
+		if term.desc {
+			direction = "DESC"
+		}
+		parts[i] = term.column.expr + " " + direction
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// seekClause renders the keyset condition that starts after the cursor:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys
+func seekClause(terms []sortTerm, values []string, args *[]interface{}) (string, error) {
+	if len(values) != len(terms) {
+		return "", models.NewValidationError("cursor", "invalid cursor")
+	}
+
+	bound := make([]interface{}, len(terms))
+	for i, term := range terms {
+		bound[i] = values[i]
+		switch term.column.kind {
+		case intColumn:
+			n, err := strconv.Atoi(values[i])
+			if err != nil {
+				return "", models.NewValidationError("cursor", "invalid cursor")
+			}
+			bound[i] = n
+		case numberColumn:
+			f, err := strconv.ParseFloat(values[i], 64)
+			if err != nil {
+				return "", models.NewValidationError("cursor", "invalid cursor")
+			}
+			bound[i] = f
+		}
+	}
+
+	var alternatives []string
+	for i, term := range terms {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, terms[j].column.expr+" = ?")
+			*args = append(*args, bound[j])
+		}
+
+		op := ">"
+		if term.desc {
+			op = "<"
+		}
+		parts = append(parts, term.column.expr+" "+op+" ?")
+		*args = append(*args, bound[i])
+
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+// cursorValues reads the sort keys of the last book on a page
+func cursorValues(terms []sortTerm, book models.Book) []string {
+	values := make([]string, len(terms))
+	for i, term := range terms {
+		values[i] = term.column.value(book)
+	}
+	return values
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/filterql"
+)
+
+// compile parses and compiles a filter the way GetByFilters does
+func compile(t *testing.T, input string) (string, []interface{}, error) {
+	t.Helper()
+	expr, err := filterql.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", input, err)
+	}
+	var args []interface{}
+	sql, err := compileFilter(expr, &args)
+	return sql, args, err
+}
+
+func TestCompileFilter(t *testing.T) {
+	tests := []struct {
+		input string
+		sql   string
+		args  []interface{}
+	}{
+		{`title:Dune`, `title = ?`, []interface{}{"Dune"}},
+		{`status!=read`, `status != ?`, []interface{}{"read"}},
+		{`category:sci-fi`, `COALESCE(category, '') = ?`, []interface{}{"sci-fi"}},
+		{`page_count>300`, `page_count > ?`, []interface{}{300}},
+		{`rating>=4.5`, `COALESCE(rating, 0) >= ?`, []interface{}{4.5}},
+		{`series_position<=2`, `COALESCE(series_position, 0) <= ?`, []interface{}{2.0}},
+		{`end_date<2025-01-01`, `date(COALESCE(end_date, '')) < date(?)`, []interface{}{"2025-01-01"}},
+		{`start_date:"2025-03-01T10:00:00Z"`, `date(start_date) = date(?)`, []interface{}{"2025-03-01T10:00:00Z"}},
+
+		// in(...) binds one placeholder per value
+		{`status:in(reading,to_read)`, `status IN (?, ?)`, []interface{}{"reading", "to_read"}},
+		{`id:in(1,2,3)`, `id IN (?, ?, ?)`, []interface{}{1, 2, 3}},
+		{`end_date:in(2025-01-01)`, `date(COALESCE(end_date, '')) IN (date(?))`, []interface{}{"2025-01-01"}},
+
+		// :~ matches a substring with LIKE wildcards escaped
+		{`author:~herbert`, `author LIKE ? ESCAPE '\'`, []interface{}{"%herbert%"}},
+		{`title:~"100% _real_"`, `title LIKE ? ESCAPE '\'`, []interface{}{`%100\% \_real\_%`}},
+		{`notes:~"C:\\dir"`, `COALESCE(notes, '') LIKE ? ESCAPE '\'`, []interface{}{`%C:\\dir%`}},
+
+		// Logical operators keep the parser's grouping with parentheses
+		{`title:a OR title:b AND status:read`, `(title = ? OR (title = ? AND status = ?))`, []interface{}{"a", "b", "read"}},
+		{`(title:a OR title:b) AND status:read`, `((title = ? OR title = ?) AND status = ?)`, []interface{}{"a", "b", "read"}},
+		{`NOT status:in(read,abandoned) AND rating>3`, `(NOT (status IN (?, ?)) AND COALESCE(rating, 0) > ?)`, []interface{}{"read", "abandoned", 3.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			sql, args, err := compile(t, tt.input)
+			if err != nil {
+				t.Fatalf("compileFilter error: %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		token   string
+		message string
+	}{
+		{`password:x`, 0, "password", "unknown field"},
+		{`title:a AND secret:b`, 12, "secret", "unknown field"},
+		{`rating:~4`, 0, "rating", "operator :~ only applies to text fields"},
+		{`end_date:~2025`, 0, "end_date", "operator :~ only applies to text fields"},
+		{`page_count>many`, 11, "many", "expected a whole number"},
+		{`id:in(1,2,x)`, 10, "x", "expected a whole number"},
+		{`rating>=high`, 8, "high", "expected a number"},
+		{`end_date>=yesterday`, 10, "yesterday", "expected a date like 2025-01-31, today, year_start, month_start or -30d"},
+		{`end_date>2025-13-01`, 9, "2025-13-01", "expected a date like 2025-01-31, today, year_start, month_start or -30d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, _, err := compile(t, tt.input)
+			var filterErr *filterql.Error
+			if !errors.As(err, &filterErr) {
+				t.Fatalf("compileFilter error = %v, want *filterql.Error", err)
+			}
+			want := filterql.Error{Param: "filter", Pos: tt.pos, Token: tt.token, Message: tt.message}
+			if *filterErr != want {
+				t.Errorf("compileFilter error = %+v, want %+v", *filterErr, want)
+			}
+		})
+	}
+}
+
+// TestCompileFilterBindsValues checks that values only ever reach the
+// database as bind parameters: whatever a value contains, the SQL is the
+// same as for a harmless one and the value shows up verbatim in args
+func TestCompileFilterBindsValues(t *testing.T) {
+	hostile := []string{
+		`'; DROP TABLE books; --`,
+		`x' OR '1'='1`,
+		`") OR 1=1 --`,
+		`?`,
+		`%`,
+		`\`,
+		`date('now')`,
+		"line\nbreak",
+		"nul\x00byte",
+	}
+
+	for _, value := range hostile {
+		quoted := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+		for _, tmpl := range []string{`title:%s`, `notes!=%s`, `status:in(read,%s)`, `author:~%s`} {
+			input := strings.Replace(tmpl, "%s", quoted, 1)
+			safe := strings.Replace(tmpl, "%s", "safe", 1)
+
+			sql, args, err := compile(t, input)
+			if err != nil {
+				t.Errorf("compileFilter(%q) error: %v", input, err)
+				continue
+			}
+			safeSQL, _, err := compile(t, safe)
+			if err != nil {
+				t.Fatalf("compileFilter(%q) error: %v", safe, err)
+			}
+
+			if sql != safeSQL {
+				t.Errorf("compileFilter(%q) = %q, want %q", input, sql, safeSQL)
+			}
+			if strings.Count(sql, "?") != len(args) {
+				t.Errorf("compileFilter(%q) = %q has %d args", input, sql, len(args))
+			}
+			if !containsArg(args, value) {
+				t.Errorf("compileFilter(%q) args = %#v, want them to contain %q", input, args, value)
+			}
+		}
+	}
+}
+
+// containsArg reports whether value was bound as an argument, directly or
+// as a LIKE pattern
+func containsArg(args []interface{}, value string) bool {
+	for _, arg := range args {
+		if arg == value || arg == "%"+escapeLike(value)+"%" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCompileFilterRelativeDates(t *testing.T) {
+	for _, input := range []string{`end_date>=today`, `end_date>=year_start`, `start_date<month_start`, `end_date>-30d`, `end_date:in(-1w,-2y)`} {
+		sql, args, err := compile(t, input)
+		if err != nil {
+			t.Errorf("compileFilter(%q) error: %v", input, err)
+			continue
+		}
+		if strings.Count(sql, "date(?)") != len(args) {
+			t.Errorf("compileFilter(%q) = %q, want every date bound as date(?)", input, sql)
+		}
+		for _, arg := range args {
+			if _, err := time.Parse("2006-01-02", arg.(string)); err != nil {
+				t.Errorf("compileFilter(%q) bound %q, want a resolved date", input, arg)
+			}
+		}
+	}
+}
+
+func TestRelativeDate(t *testing.T) {
+	now := time.Date(2025, time.March, 31, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"today", "2025-03-31"},
+		{"year_start", "2025-01-01"},
+		{"month_start", "2025-03-01"},
+		{"-0d", "2025-03-31"},
+		{"-1d", "2025-03-30"},
+		{"-31d", "2025-02-28"},
+		{"-2w", "2025-03-17"},
+		{"-12m", "2024-03-31"},
+		{"-1y", "2024-03-31"},
+	}
+
+	for _, tt := range tests {
+		got, ok := relativeDate(tt.raw, now)
+		if !ok {
+			t.Errorf("relativeDate(%q) was not recognized", tt.raw)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.want || got.Hour() != 0 || got.Minute() != 0 {
+			t.Errorf("relativeDate(%q) = %s, want %s at midnight", tt.raw, got, tt.want)
+		}
+	}
+
+	for _, raw := range []string{"", "now", "Today", "-d", "30d", "+30d", "--1d", "-1h", "-1.5d", "-xd"} {
+		if got, ok := relativeDate(raw, now); ok {
+			t.Errorf("relativeDate(%q) = %s, want it rejected", raw, got)
+		}
+	}
+}
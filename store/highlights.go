@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// highlightSelectColumns lists the highlights columns scanHighlight reads, in order
+var highlightSelectColumns = []string{
+	"id", "book_id", "text", "page", "location", "comment", "color", "created_at",
+}
+
+// selectHighlightColumns renders highlightSelectColumns for a SELECT,
+// qualified with a table alias when one is given
+func selectHighlightColumns(alias string) string {
+	if alias == "" {
+		return strings.Join(highlightSelectColumns, ", ")
+	}
+	return alias + "." + strings.Join(highlightSelectColumns, ", "+alias+".")
+}
+
+// CreateHighlight saves a passage from a book
+func (s *SQLiteStore) CreateHighlight(ctx context.Context, bookID int, highlight models.Highlight) (models.Highlight, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if _, err := getBook(ctx, tx, bookID); err != nil {
+		return models.Highlight{}, err
+	}
+
+	highlight.BookID = bookID
+	if highlight.CreatedAt.IsZero() {
+		highlight.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO highlights (book_id, text, page, location, comment, color, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		bookID, highlight.Text, highlight.Page, highlight.Location, highlight.Comment, highlight.Color,
+		highlight.CreatedAt.Format(time.RFC3339),
+	)
+	if err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to save highlight: %w", translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to read highlight ID: %w", translateError(err))
+	}
+	highlight.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to commit highlight: %w", translateError(err))
+	}
+	return highlight, nil
+}
+
+// GetHighlights returns a book's highlights in page order
+func (s *SQLiteStore) GetHighlights(ctx context.Context, bookID int) ([]models.Highlight, error) {
+	if _, err := s.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + selectHighlightColumns("") + `
+		FROM highlights
+		WHERE book_id = ?
+		ORDER BY page IS NULL, page, location, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query highlights of book %d: %w", bookID, translateError(err))
+	}
+	defer rows.Close()
+
+	highlights := []models.Highlight{}
+	for rows.Next() {
+		highlight, err := scanHighlight(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan highlight: %w", err)
+		}
+		highlights = append(highlights, highlight)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read highlights: %w", translateError(err))
+	}
+	return highlights, nil
+}
+
+// UpdateHighlight replaces the text, position, comment and color of a highlight
+func (s *SQLiteStore) UpdateHighlight(ctx context.Context, bookID int, highlight models.Highlight) (models.Highlight, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE highlights SET text = ?, page = ?, location = ?, comment = ?, color = ?
+		WHERE id = ? AND book_id = ?`,
+		highlight.Text, highlight.Page, highlight.Location, highlight.Comment, highlight.Color,
+		highlight.ID, bookID,
+	)
+	if err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to update highlight %d: %w", highlight.ID, translateError(err))
+	}
+	if err := highlightChanged(ctx, tx, bookID, highlight.ID, result); err != nil {
+		return models.Highlight{}, err
+	}
+
+	updated, err := scanHighlight(tx.QueryRowContext(ctx,
+		`SELECT `+selectHighlightColumns("")+` FROM highlights WHERE id = ?`, highlight.ID,
+	))
+	if err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to read highlight %d: %w", highlight.ID, translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Highlight{}, fmt.Errorf("failed to commit highlight: %w", translateError(err))
+	}
+	return updated, nil
+}
+
+// DeleteHighlight removes a highlight from a book
+func (s *SQLiteStore) DeleteHighlight(ctx context.Context, bookID, highlightID int) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM highlights WHERE id = ? AND book_id = ?`, highlightID, bookID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete highlight %d: %w", highlightID, translateError(err))
+	}
+	return highlightChanged(ctx, s.db, bookID, highlightID, result)
+}
+
+// SearchHighlights runs a full-text query over the text and comments of
+// every highlight, best match first. Text hits weigh more than comments.
+func (s *SQLiteStore) SearchHighlights(ctx context.Context, q string, limit int) ([]models.HighlightResult, error) {
+	match, err := buildMatchQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + selectHighlightColumns("h") + `, b.title, b.author,
+			` + snippetColumn("highlights_fts", 0, 24) + `,
+			bm25(highlights_fts, 2.0, 1.0) AS rank
+		FROM highlights_fts
+		JOIN highlights h ON h.id = highlights_fts.rowid
+		JOIN books b ON b.id = h.book_id
+		WHERE highlights_fts MATCH ?
+		ORDER BY rank
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search highlights: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	results := []models.HighlightResult{}
+	for rows.Next() {
+		var result models.HighlightResult
+		result.Highlight, err = scanHighlight(rows, &result.BookTitle, &result.BookAuthor, &result.Snippet, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan highlight result: %w", err)
+		}
+		result.Snippet = snippetHTML(result.Snippet)
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read highlight results: %w", translateError(err))
+	}
+	return results, nil
+}
+
+// DailyHighlight picks the highlight of the given day. The pick is
+// random-looking but stable, so every request on the same day gets the
+// same highlight as long as the collection doesn't change.
+func (s *SQLiteStore) DailyHighlight(ctx context.Context, day time.Time) (models.HighlightResult, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM highlights`).Scan(&count); err != nil {
+		return models.HighlightResult{}, fmt.Errorf("failed to count highlights: %w", translateError(err))
+	}
+	if count == 0 {
+		return models.HighlightResult{}, fmt.Errorf("highlight %w", models.ErrNotFound)
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(day.Format("2006-01-02")))
+	offset := int(hash.Sum32() % uint32(count))
+
+	query := `
+		SELECT ` + selectHighlightColumns("h") + `, b.title, b.author
+		FROM highlights h
+		JOIN books b ON b.id = h.book_id
+		ORDER BY h.id
+		LIMIT 1 OFFSET ?
+	`
+
+	var result models.HighlightResult
+	var err error
+	result.Highlight, err = scanHighlight(s.db.QueryRowContext(ctx, query, offset), &result.BookTitle, &result.BookAuthor)
+	if err == sql.ErrNoRows {
+		return models.HighlightResult{}, fmt.Errorf("highlight %w", models.ErrNotFound)
+	}
+	if err != nil {
+		return models.HighlightResult{}, fmt.Errorf("failed to pick highlight: %w", translateError(err))
+	}
+	return result, nil
+}
+
+// highlightChanged reports whether a write hit the highlight, telling a
+// missing book apart from a missing highlight
+func highlightChanged(ctx context.Context, q querier, bookID, highlightID int, result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to change highlight %d: %w", highlightID, translateError(err))
+	}
+	if n == 0 {
+		if _, err := getBook(ctx, q, bookID); err != nil {
+			return err
+		}
+		return fmt.Errorf("highlight %d %w", highlightID, models.ErrNotFound)
+	}
+	return nil
+}
+
+// scanHighlight scans a row selected with selectHighlightColumns, plus
+// any extra columns that follow
+func scanHighlight(row rowScanner, extra ...interface{}) (models.Highlight, error) {
+	var highlight models.Highlight
+	var page sql.NullInt64
+	var createdAt string
+
+	dest := []interface{}{
+		&highlight.ID,
+		&highlight.BookID,
+		&highlight.Text,
+		&page,
+		&highlight.Location,
+		&highlight.Comment,
+		&highlight.Color,
+		&createdAt,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return highlight, err
+	}
+
+	if page.Valid {
+		p := int(page.Int64)
+		highlight.Page = &p
+	}
+	highlight.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+	return highlight, nil
+}
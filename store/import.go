@@ -0,0 +1,256 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/favxlaw/filterql"
+	"github.com/favxlaw/models"
+)
+
+// ImportBooks creates and updates books row by row in one transaction.
+// Rows that fail with a domain error (validation, conflict, missing or
+// stale book) are reported as rejected and the rest carry on, so one call
+// lists every problem. The transaction is only committed when commit is
+// set and no row was rejected; otherwise it is rolled back, which makes a
+// dry run exercise exactly the same checks as the real import.
+func (s *SQLiteStore) ImportBooks(ctx context.Context, rows []models.ImportRow, commit bool) ([]models.ImportResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	results := make([]models.ImportResult, 0, len(rows))
+	rejected := false
+	workKeys := map[int]int{}
+
+	for _, row := range rows {
+		result := models.ImportResult{Line: row.Line, BookID: row.Book.ID, Title: row.Book.Title}
+
+		// Savepoints undo whatever a failed row already wrote
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+			return nil, fmt.Errorf("failed to import line %d: %w", row.Line, translateError(err))
+		}
+
+		action, id, err := importRow(ctx, tx, row, workKeys)
+		if err != nil {
+			if !isRowError(err) {
+				return nil, fmt.Errorf("failed to import line %d: %w", row.Line, err)
+			}
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO import_row`); err != nil {
+				return nil, fmt.Errorf("failed to import line %d: %w", row.Line, translateError(err))
+			}
+			rejected = true
+			result.Action = models.ImportRejected
+			result.Error = rowErrorMessage(err)
+		} else {
+			result.Action = action
+			result.BookID = id
+		}
+
+		if _, err := tx.ExecContext(ctx, `RELEASE import_row`); err != nil {
+			return nil, fmt.Errorf("failed to import line %d: %w", row.Line, translateError(err))
+		}
+		results = append(results, result)
+	}
+
+	if !commit || rejected {
+		// IDs of books that were never committed would be misleading
+		for i := range results {
+			if results[i].Action == models.ImportCreated {
+				results[i].BookID = 0
+			}
+		}
+		return results, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", translateError(err))
+	}
+	return results, nil
+}
+
+// MatchBook finds the book an imported record refers to: the book with
+// the ISBN, else the one with exactly this title and author. A title
+// match with another ISBN is a different edition and doesn't count.
+// Empty arguments are not matched on; nil means nothing matched.
+func (s *SQLiteStore) MatchBook(ctx context.Context, isbn, title, author string) (*models.Book, error) {
+	if isbn != "" {
+		book, err := s.firstBook(ctx, models.BookFilter{ISBN: isbn})
+		if book != nil || err != nil {
+			return book, err
+		}
+	}
+
+	if title == "" || author == "" {
+		return nil, nil
+	}
+
+	book, err := s.firstBook(ctx, models.BookFilter{Where: &filterql.Logical{
+		Op:    "AND",
+		Left:  &filterql.Comparison{Field: "title", Op: filterql.OpEq, Values: []string{title}},
+		Right: &filterql.Comparison{Field: "author", Op: filterql.OpEq, Values: []string{author}},
+	}})
+	if err != nil || book == nil || (book.ISBN != "" && book.ISBN != isbn) {
+		return nil, err
+	}
+	return book, nil
+}
+
+// firstBook returns the first book matching filter, or nil
+func (s *SQLiteStore) firstBook(ctx context.Context, filter models.BookFilter) (*models.Book, error) {
+	filter.Limit = 1
+	page, err := s.GetByFilters(ctx, filter)
+	if err != nil || len(page.Books) == 0 {
+		return nil, err
+	}
+	return &page.Books[0], nil
+}
+
+// importRow saves one imported book, skipping updates that change nothing.
+// workKeys maps the work keys seen so far to the works they ended up in.
+func importRow(ctx context.Context, tx *sql.Tx, row models.ImportRow, workKeys map[int]int) (models.ImportAction, int, error) {
+	book := row.Book
+	if workID, ok := workKeys[row.WorkKey]; ok && row.WorkKey != 0 {
+		book.WorkID = workID
+	}
+	if row.SeriesName != "" {
+		seriesID, err := ensureSeries(ctx, tx, row.SeriesName)
+		if err != nil {
+			return "", 0, err
+		}
+		book.SeriesID = &seriesID
+	}
+
+	action, id, err := saveImportedBook(ctx, tx, book)
+	if err != nil || row.WorkKey == 0 {
+		return action, id, err
+	}
+
+	if _, ok := workKeys[row.WorkKey]; !ok {
+		var workID int
+		err := tx.QueryRowContext(ctx, `SELECT work_id FROM books WHERE id = ?`, id).Scan(&workID)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read work of book %d: %w", id, translateError(err))
+		}
+		workKeys[row.WorkKey] = workID
+	}
+	return action, id, nil
+}
+
+// saveImportedBook creates or updates book, skipping updates that change
+// nothing
+func saveImportedBook(ctx context.Context, tx *sql.Tx, book models.Book) (models.ImportAction, int, error) {
+	if book.ID == 0 {
+		created, err := insertBook(ctx, tx, book)
+		if err != nil {
+			return "", 0, err
+		}
+		return models.ImportCreated, created.ID, nil
+	}
+
+	existing, err := getBook(ctx, tx, book.ID)
+	if err != nil {
+		return "", 0, fmt.Errorf("book %d: %w", book.ID, err)
+	}
+	if book.Version != 0 && book.Version != existing.Version {
+		return "", 0, fmt.Errorf("book %d changed during the import: %w", book.ID, models.ErrPreconditionFailed)
+	}
+	if unchangedBook(*existing, book) {
+		return models.ImportSkipped, book.ID, nil
+	}
+
+	if err := updateBook(ctx, tx, book.ID, book); err != nil {
+		return "", 0, err
+	}
+	return models.ImportUpdated, book.ID, nil
+}
+
+// unchangedBook reports whether saving book over existing would change
+// any stored field. Computed fields are ignored, and nil Tags or Authors
+// mean the existing ones are kept. Credits given by name match the stored
+// ones by author name key.
+func unchangedBook(existing, book models.Book) bool {
+	if !existing.StartDate.Equal(book.StartDate) || !sameTime(existing.EndDate, book.EndDate) {
+		return false
+	}
+
+	if book.Tags == nil {
+		book.Tags = existing.Tags
+	}
+	if book.Authors == nil || sameCredits(existing.Authors, book.Authors) {
+		book.Authors = existing.Authors
+	}
+
+	for _, b := range []*models.Book{&existing, &book} {
+		b.StartDate, b.EndDate = existing.StartDate, nil
+		b.Version = 0
+		b.Sessions = models.SessionStats{}
+		b.SeriesName = ""
+		b.Readings, b.Loan, b.Cover = nil, nil, nil
+		b.Tags = uniqueTags(b.Tags)
+		sort.Strings(b.Tags)
+		if b.WorkID == 0 {
+			b.WorkID = b.ID
+		}
+	}
+
+	return reflect.DeepEqual(existing, book)
+}
+
+// sameCredits reports whether credits name the same authors in the same
+// roles and order as the resolved credits existing
+func sameCredits(existing, credits []models.BookAuthor) bool {
+	if len(existing) != len(credits) {
+		return false
+	}
+	for i, credit := range credits {
+		if credit.Role == "" {
+			credit.Role = models.RoleAuthor
+		}
+		if credit.Role != existing[i].Role {
+			return false
+		}
+		if credit.AuthorID != 0 {
+			if credit.AuthorID != existing[i].AuthorID {
+				return false
+			}
+		} else if authorNameKey(cleanAuthorName(credit.Name)) != authorNameKey(existing[i].Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTime compares optional times by instant
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// isRowError reports whether err is caused by the row itself rather than
+// by the database
+func isRowError(err error) bool {
+	var validationErr *models.ValidationError
+	return errors.As(err, &validationErr) ||
+		errors.Is(err, models.ErrConflict) ||
+		errors.Is(err, models.ErrNotFound) ||
+		errors.Is(err, models.ErrPreconditionFailed)
+}
+
+// rowErrorMessage is the message reported for a rejected row
+func rowErrorMessage(err error) string {
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Message
+	}
+	return err.Error()
+}
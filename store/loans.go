@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// loanSelectColumns lists the loans columns scanLoan reads, in order
+var loanSelectColumns = []string{
+	"id", "book_id", "borrower", "contact", "lent_date", "due_date", "returned_date",
+}
+
+// selectLoanColumns renders loanSelectColumns for a SELECT, qualified
+// with a table alias when one is given
+func selectLoanColumns(alias string) string {
+	if alias == "" {
+		return strings.Join(loanSelectColumns, ", ")
+	}
+	return alias + "." + strings.Join(loanSelectColumns, ", "+alias+".")
+}
+
+// LendBook lends a book out. A book that is already out can't be lent
+// again until it is returned.
+func (s *SQLiteStore) LendBook(ctx context.Context, bookID int, loan models.Loan) (models.Loan, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Loan{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	book, err := getBook(ctx, tx, bookID)
+	if err != nil {
+		return models.Loan{}, err
+	}
+	if book.Loan != nil {
+		return models.Loan{}, fmt.Errorf("book %d is already lent to %s: %w", bookID, book.Loan.Borrower, models.ErrConflict)
+	}
+
+	loan.BookID = bookID
+	loan.ReturnedDate = nil
+	if loan.LentDate.IsZero() {
+		loan.LentDate = time.Now().UTC().Truncate(time.Second)
+	}
+
+	var dueDate interface{}
+	if loan.DueDate != nil {
+		dueDate = loan.DueDate.Format(time.RFC3339)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO loans (book_id, borrower, contact, lent_date, due_date) VALUES (?, ?, ?, ?, ?)`,
+		bookID, loan.Borrower, loan.Contact, loan.LentDate.Format(time.RFC3339), dueDate,
+	)
+	if err != nil {
+		return models.Loan{}, fmt.Errorf("failed to lend book %d: %w", bookID, translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Loan{}, fmt.Errorf("failed to read loan ID: %w", translateError(err))
+	}
+	loan.ID = int(id)
+
+	if err := touchBook(ctx, tx, bookID); err != nil {
+		return models.Loan{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Loan{}, fmt.Errorf("failed to commit loan: %w", translateError(err))
+	}
+	return loan, nil
+}
+
+// ReturnBook closes the loan a book is out on
+func (s *SQLiteStore) ReturnBook(ctx context.Context, bookID int, returned time.Time) (models.Loan, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Loan{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	book, err := getBook(ctx, tx, bookID)
+	if err != nil {
+		return models.Loan{}, err
+	}
+	if book.Loan == nil {
+		return models.Loan{}, fmt.Errorf("loan of book %d %w", bookID, models.ErrNotFound)
+	}
+
+	loan := *book.Loan
+	if returned.Before(loan.LentDate) {
+		return models.Loan{}, models.NewValidationError("ReturnedDate", "a book can't be returned before it was lent")
+	}
+	loan.ReturnedDate = &returned
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE loans SET returned_date = ? WHERE id = ?`,
+		returned.Format(time.RFC3339), loan.ID,
+	)
+	if err != nil {
+		return models.Loan{}, fmt.Errorf("failed to return loan %d: %w", loan.ID, translateError(err))
+	}
+
+	if err := touchBook(ctx, tx, bookID); err != nil {
+		return models.Loan{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Loan{}, fmt.Errorf("failed to commit return: %w", translateError(err))
+	}
+	return loan, nil
+}
+
+// GetLoans returns a book's loan history, most recent first
+func (s *SQLiteStore) GetLoans(ctx context.Context, bookID int) ([]models.Loan, error) {
+	if _, err := s.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+selectLoanColumns("")+` FROM loans WHERE book_id = ? ORDER BY lent_date DESC, id DESC`,
+		bookID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loans of book %d: %w", bookID, translateError(err))
+	}
+	defer rows.Close()
+
+	loans := []models.Loan{}
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan loan: %w", err)
+		}
+		loans = append(loans, loan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read loans: %w", translateError(err))
+	}
+	return loans, nil
+}
+
+// ListLoans returns the books currently lent out, earliest due first.
+// With overdueOn set, only loans due before that day are included.
+func (s *SQLiteStore) ListLoans(ctx context.Context, overdueOn *time.Time) ([]models.LoanListing, error) {
+	query := `
+		SELECT ` + selectLoanColumns("l") + `, b.title, b.author
+		FROM loans l
+		JOIN books b ON b.id = l.book_id
+		WHERE l.returned_date IS NULL
+	`
+	args := []interface{}{}
+
+	if overdueOn != nil {
+		query += ` AND l.due_date IS NOT NULL AND date(l.due_date) < date(?)`
+		args = append(args, overdueOn.Format("2006-01-02"))
+	}
+	query += ` ORDER BY l.due_date IS NULL, l.due_date, l.lent_date`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loans: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	listings := []models.LoanListing{}
+	for rows.Next() {
+		var listing models.LoanListing
+		listing.Loan, err = scanLoan(rows, &listing.BookTitle, &listing.BookAuthor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan loan: %w", err)
+		}
+		listings = append(listings, listing)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read loans: %w", translateError(err))
+	}
+	return listings, nil
+}
+
+// touchBook bumps a book's version so its ETag changes when computed
+// parts of it, like the current loan, do
+func touchBook(ctx context.Context, q querier, bookID int) error {
+	_, err := q.ExecContext(ctx, `UPDATE books SET version = version + 1 WHERE id = ?`, bookID)
+	if err != nil {
+		return fmt.Errorf("failed to update book %d: %w", bookID, translateError(err))
+	}
+	return nil
+}
+
+// loadLoans fills in the current loan of each book with one query
+func loadLoans(ctx context.Context, q querier, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(books))
+	args := make([]interface{}, len(books))
+	for i := range books {
+		index[books[i].ID] = i
+		args[i] = books[i].ID
+		books[i].Loan = nil
+	}
+
+	query := `
+		SELECT ` + selectLoanColumns("") + `
+		FROM loans
+		WHERE returned_date IS NULL AND book_id IN (` + placeholders(len(books)) + `)
+	`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load loans: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan loan: %w", err)
+		}
+		books[index[loan.BookID]].Loan = &loan
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read loans: %w", translateError(err))
+	}
+	return nil
+}
+
+// scanLoan scans a row selected with selectLoanColumns, plus any extra
+// columns that follow
+func scanLoan(row rowScanner, extra ...interface{}) (models.Loan, error) {
+	var loan models.Loan
+	var lentDate string
+	var dueDate, returnedDate sql.NullString
+
+	dest := []interface{}{
+		&loan.ID,
+		&loan.BookID,
+		&loan.Borrower,
+		&loan.Contact,
+		&lentDate,
+		&dueDate,
+		&returnedDate,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return loan, err
+	}
+
+	loan.LentDate, _ = time.Parse(time.RFC3339, lentDate)
+	if dueDate.Valid {
+		due, _ := time.Parse(time.RFC3339, dueDate.String)
+		loan.DueDate = &due
+	}
+	if returnedDate.Valid {
+		returned, _ := time.Parse(time.RFC3339, returnedDate.String)
+		loan.ReturnedDate = &returned
+	}
+
+	return loan, nil
+}
@@ -2,16 +2,25 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 )
 
+// ErrNoFTS5 is returned when the SQLite driver was compiled without the
+// FTS5 module that full-text search needs
+var ErrNoFTS5 = errors.New("SQLite was built without FTS5; build with -tags sqlite_fts5")
+
 // Migration represents a database migration
 type Migration struct {
 	Version     int
 	Description string
 	Up          string
 	Down        string
+
+	// Backfill optionally moves existing data after Up has run, for
+	// transformations that are awkward to express in SQL
+	Backfill func(db *sql.DB) error
 }
 
 // migrations is the list of all migrations in order
@@ -44,10 +53,422 @@ var migrations = []Migration{
 		`,
 		Down: `DROP TABLE IF EXISTS schema_migrations;`,
 	},
+	{
+		Version:     3,
+		Description: "Add version column to books for optimistic concurrency",
+		Up: `
+			ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+		`,
+		Down: `ALTER TABLE books DROP COLUMN version;`,
+	},
+	{
+		Version:     4,
+		Description: "Create books_fts full-text index over title, author and notes",
+		Up: `
+			CREATE VIRTUAL TABLE IF NOT EXISTS books_fts USING fts5(
+				title,
+				author,
+				notes,
+				content = 'books',
+				content_rowid = 'id',
+				tokenize = 'unicode61 remove_diacritics 2',
+				prefix = '2 3'
+			);
+
+			INSERT INTO books_fts (books_fts) VALUES ('rebuild');
+
+			CREATE TRIGGER IF NOT EXISTS books_fts_insert AFTER INSERT ON books BEGIN
+				INSERT INTO books_fts (rowid, title, author, notes)
+				VALUES (new.id, new.title, new.author, new.notes);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS books_fts_delete AFTER DELETE ON books BEGIN
+				INSERT INTO books_fts (books_fts, rowid, title, author, notes)
+				VALUES ('delete', old.id, old.title, old.author, old.notes);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS books_fts_update AFTER UPDATE OF title, author, notes ON books BEGIN
+				INSERT INTO books_fts (books_fts, rowid, title, author, notes)
+				VALUES ('delete', old.id, old.title, old.author, old.notes);
+				INSERT INTO books_fts (rowid, title, author, notes)
+				VALUES (new.id, new.title, new.author, new.notes);
+			END;
+		`,
+		Down: `
+			DROP TRIGGER IF EXISTS books_fts_update;
+			DROP TRIGGER IF EXISTS books_fts_delete;
+			DROP TRIGGER IF EXISTS books_fts_insert;
+			DROP TABLE IF EXISTS books_fts;
+		`,
+	},
+	{
+		Version:     5,
+		Description: "Create authors and book_authors tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS authors (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				name_key TEXT NOT NULL UNIQUE
+			);
+
+			CREATE TABLE IF NOT EXISTS book_authors (
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				author_id INTEGER NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+				role TEXT NOT NULL DEFAULT 'author',
+				position INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (book_id, author_id, role)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_book_authors_author ON book_authors(author_id);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS book_authors;
+			DROP TABLE IF EXISTS authors;
+		`,
+		Backfill: backfillAuthors,
+	},
+	{
+		Version:     6,
+		Description: "Create tags and book_tags tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS tags (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE
+			);
+
+			CREATE TABLE IF NOT EXISTS book_tags (
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+				PRIMARY KEY (book_id, tag_id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_book_tags_tag ON book_tags(tag_id);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS book_tags;
+			DROP TABLE IF EXISTS tags;
+		`,
+	},
+	{
+		Version:     7,
+		Description: "Add page_count to books and create reading_progress table",
+		Up: `
+			ALTER TABLE books ADD COLUMN page_count INTEGER NOT NULL DEFAULT 0;
+
+			CREATE TABLE IF NOT EXISTS reading_progress (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				page INTEGER,
+				percent REAL,
+				location TEXT,
+				logged_at DATETIME NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_reading_progress_book ON reading_progress(book_id, logged_at);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS reading_progress;
+			ALTER TABLE books DROP COLUMN page_count;
+		`,
+	},
+	{
+		Version:     8,
+		Description: "Create reading_sessions table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS reading_sessions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				reader TEXT NOT NULL DEFAULT '',
+				started_at DATETIME NOT NULL,
+				ended_at DATETIME,
+				pages_read INTEGER NOT NULL DEFAULT 0,
+				note TEXT NOT NULL DEFAULT ''
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_reading_sessions_book ON reading_sessions(book_id, started_at);
+			CREATE INDEX IF NOT EXISTS idx_reading_sessions_reader ON reading_sessions(reader, started_at);
+
+			-- A reader can only have one session running at a time
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_reading_sessions_open
+				ON reading_sessions(reader) WHERE ended_at IS NULL;
+		`,
+		Down: `DROP TABLE IF EXISTS reading_sessions;`,
+	},
+	{
+		Version:     9,
+		Description: "Create readings table from start_date/end_date",
+		Up: `
+			CREATE TABLE IF NOT EXISTS readings (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				start_date DATETIME NOT NULL,
+				end_date DATETIME,
+				outcome TEXT CHECK (outcome IN ('finished', 'abandoned')),
+				rating REAL,
+				CHECK ((end_date IS NULL) = (outcome IS NULL))
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_readings_book ON readings(book_id, start_date);
+
+			-- A book has at most one read-through in progress
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_readings_open
+				ON readings(book_id) WHERE end_date IS NULL;
+
+			-- Every book that was ever started gets its current dates as its first read-through
+			INSERT INTO readings (book_id, start_date, end_date, outcome)
+			SELECT id, start_date,
+				CASE WHEN status IN ('finished', 'abandoned') THEN COALESCE(end_date, start_date) END,
+				CASE WHEN status IN ('finished', 'abandoned') THEN status END
+			FROM books
+			WHERE status IN ('reading', 'finished', 'abandoned');
+		`,
+		Down: `DROP TABLE IF EXISTS readings;`,
+	},
+	{
+		Version:     10,
+		Description: "Add rating to books and create reviews table",
+		Up: `
+			ALTER TABLE books ADD COLUMN rating REAL;
+
+			CREATE TABLE IF NOT EXISTS reviews (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				reading_id INTEGER REFERENCES readings(id) ON DELETE CASCADE,
+				body TEXT NOT NULL,
+				spoiler INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+
+			-- One review per read-through, plus one for the book as a whole
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_target
+				ON reviews(book_id, COALESCE(reading_id, 0));
+		`,
+		Down: `
+			DROP TABLE IF EXISTS reviews;
+			ALTER TABLE books DROP COLUMN rating;
+		`,
+	},
+	{
+		Version:     11,
+		Description: "Create highlights table and highlights_fts full-text index",
+		Up: `
+			CREATE TABLE IF NOT EXISTS highlights (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				text TEXT NOT NULL,
+				page INTEGER,
+				location TEXT NOT NULL DEFAULT '',
+				comment TEXT NOT NULL DEFAULT '',
+				color TEXT NOT NULL DEFAULT 'yellow',
+				created_at DATETIME NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_highlights_book ON highlights(book_id, page);
+
+			CREATE VIRTUAL TABLE IF NOT EXISTS highlights_fts USING fts5(
+				text,
+				comment,
+				content = 'highlights',
+				content_rowid = 'id',
+				tokenize = 'unicode61 remove_diacritics 2',
+				prefix = '2 3'
+			);
+
+			CREATE TRIGGER IF NOT EXISTS highlights_fts_insert AFTER INSERT ON highlights BEGIN
+				INSERT INTO highlights_fts (rowid, text, comment)
+				VALUES (new.id, new.text, new.comment);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS highlights_fts_delete AFTER DELETE ON highlights BEGIN
+				INSERT INTO highlights_fts (highlights_fts, rowid, text, comment)
+				VALUES ('delete', old.id, old.text, old.comment);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS highlights_fts_update AFTER UPDATE OF text, comment ON highlights BEGIN
+				INSERT INTO highlights_fts (highlights_fts, rowid, text, comment)
+				VALUES ('delete', old.id, old.text, old.comment);
+				INSERT INTO highlights_fts (rowid, text, comment)
+				VALUES (new.id, new.text, new.comment);
+			END;
+		`,
+		Down: `
+			DROP TRIGGER IF EXISTS highlights_fts_update;
+			DROP TRIGGER IF EXISTS highlights_fts_delete;
+			DROP TRIGGER IF EXISTS highlights_fts_insert;
+			DROP TABLE IF EXISTS highlights_fts;
+			DROP TABLE IF EXISTS highlights;
+		`,
+	},
+	{
+		Version:     12,
+		Description: "Create shelves and shelf_books tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS shelves (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+				description TEXT NOT NULL DEFAULT '',
+				visibility TEXT NOT NULL DEFAULT 'private'
+			);
+
+			-- Positions are kept dense (1..n) per shelf by the store; they are
+			-- not unique here because moving a book shifts its neighbours one
+			-- row at a time
+			CREATE TABLE IF NOT EXISTS shelf_books (
+				shelf_id INTEGER NOT NULL REFERENCES shelves(id) ON DELETE CASCADE,
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				PRIMARY KEY (shelf_id, book_id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_shelf_books_position ON shelf_books(shelf_id, position);
+			CREATE INDEX IF NOT EXISTS idx_shelf_books_book ON shelf_books(book_id);
+
+			-- Deleting a book closes the gap it leaves on its shelves
+			CREATE TRIGGER IF NOT EXISTS shelf_books_compact AFTER DELETE ON shelf_books BEGIN
+				UPDATE shelf_books SET position = position - 1
+				WHERE shelf_id = old.shelf_id AND position > old.position;
+			END;
+		`,
+		Down: `
+			DROP TRIGGER IF EXISTS shelf_books_compact;
+			DROP TABLE IF EXISTS shelf_books;
+			DROP TABLE IF EXISTS shelves;
+		`,
+	},
+	{
+		Version:     13,
+		Description: "Create smart_shelves table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS smart_shelves (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+				description TEXT NOT NULL DEFAULT '',
+				filter TEXT NOT NULL DEFAULT '',
+				sort TEXT NOT NULL DEFAULT '',
+				tags TEXT NOT NULL DEFAULT '[]',
+				match_any_tag INTEGER NOT NULL DEFAULT 0
+			);
+		`,
+		Down: `DROP TABLE IF EXISTS smart_shelves;`,
+	},
+	{
+		Version:     14,
+		Description: "Create loans table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS loans (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+				borrower TEXT NOT NULL,
+				contact TEXT NOT NULL DEFAULT '',
+				lent_date DATETIME NOT NULL,
+				due_date DATETIME,
+				returned_date DATETIME
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_loans_book ON loans(book_id, lent_date);
+
+			-- A book can only be out on one loan at a time
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_loans_open
+				ON loans(book_id) WHERE returned_date IS NULL;
+		`,
+		Down: `DROP TABLE IF EXISTS loans;`,
+	},
+	{
+		Version:     15,
+		Description: "Add edition details and works to books",
+		Up: `
+			ALTER TABLE books ADD COLUMN isbn TEXT NOT NULL DEFAULT '';
+			ALTER TABLE books ADD COLUMN publisher TEXT NOT NULL DEFAULT '';
+			ALTER TABLE books ADD COLUMN publication_year INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE books ADD COLUMN language TEXT NOT NULL DEFAULT '';
+			ALTER TABLE books ADD COLUMN format TEXT NOT NULL DEFAULT '';
+			ALTER TABLE books ADD COLUMN duration_minutes INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE books ADD COLUMN work_id INTEGER NOT NULL DEFAULT 0;
+
+			-- Every existing book is the only edition of its own work
+			UPDATE books SET work_id = id;
+
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn ON books(isbn) WHERE isbn != '';
+			CREATE INDEX IF NOT EXISTS idx_books_work ON books(work_id);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_books_work;
+			DROP INDEX IF EXISTS idx_books_isbn;
+			ALTER TABLE books DROP COLUMN work_id;
+			ALTER TABLE books DROP COLUMN duration_minutes;
+			ALTER TABLE books DROP COLUMN format;
+			ALTER TABLE books DROP COLUMN language;
+			ALTER TABLE books DROP COLUMN publication_year;
+			ALTER TABLE books DROP COLUMN publisher;
+			ALTER TABLE books DROP COLUMN isbn;
+		`,
+	},
+	{
+		Version:     16,
+		Description: "Create series table and add series position to books",
+		Up: `
+			CREATE TABLE IF NOT EXISTS series (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT ''
+			);
+
+			-- No foreign key so the column can be dropped again;
+			-- DeleteSeries detaches the books itself
+			ALTER TABLE books ADD COLUMN series_id INTEGER;
+			ALTER TABLE books ADD COLUMN series_position REAL;
+
+			CREATE INDEX IF NOT EXISTS idx_books_series ON books(series_id, series_position);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_books_series;
+			ALTER TABLE books DROP COLUMN series_position;
+			ALTER TABLE books DROP COLUMN series_id;
+			DROP TABLE IF EXISTS series;
+		`,
+	},
+	{
+		Version:     17,
+		Description: "Create covers table",
+		Up: `
+			-- The image files live on disk under the cover directory;
+			-- this records which books have one
+			CREATE TABLE IF NOT EXISTS covers (
+				book_id INTEGER PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+				content_type TEXT NOT NULL,
+				width INTEGER NOT NULL,
+				height INTEGER NOT NULL,
+				size INTEGER NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+		`,
+		Down: `DROP TABLE IF EXISTS covers;`,
+	},
+	{
+		Version:     18,
+		Description: "Add hash to covers",
+		Up: `
+			-- Hash of the cover's files, computed on upload and served as
+			-- the ETag. Covers saved before this are hashed on first read.
+			ALTER TABLE covers ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `ALTER TABLE covers DROP COLUMN hash;`,
+	},
 }
 
 // RunMigrations executes all pending migrations
 func RunMigrations(db *sql.DB) error {
+	// Without this check a plain go build fails at migration 4 with
+	// "no such module: fts5", which doesn't say how to fix it
+	var hasFTS5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&hasFTS5); err != nil {
+		return fmt.Errorf("failed to check SQLite options: %w", err)
+	}
+	if !hasFTS5 {
+		return ErrNoFTS5
+	}
+
 	// Ensure schema_migrations table exists
 	_, err := db.Exec(migrations[1].Up)
 	if err != nil {
@@ -72,6 +493,12 @@ func RunMigrations(db *sql.DB) error {
 			return fmt.Errorf("migration %d failed: %w", migration.Version, err)
 		}
 
+		if migration.Backfill != nil {
+			if err := migration.Backfill(db); err != nil {
+				return fmt.Errorf("migration %d backfill failed: %w", migration.Version, err)
+			}
+		}
+
 		// Record that we applied this migration
 		_, err = db.Exec(
 			`INSERT INTO schema_migrations (version) VALUES (?)`,
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// LogProgress records a reading position. A to_read book moves to
+// reading, and the update flags when the reader has reached the end.
+func (s *SQLiteStore) LogProgress(ctx context.Context, bookID int, entry models.ReadingProgress) (models.ProgressUpdate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ProgressUpdate{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	book, err := getBook(ctx, tx, bookID)
+	if err != nil {
+		return models.ProgressUpdate{}, err
+	}
+
+	if entry.Page != nil && book.PageCount > 0 && *entry.Page > book.PageCount {
+		return models.ProgressUpdate{}, models.NewValidationError("Page",
+			fmt.Sprintf("page %d is past the last page (%d)", *entry.Page, book.PageCount))
+	}
+
+	// Derive the percentage from the page when the length is known
+	if entry.Page != nil && entry.Percent == nil && book.PageCount > 0 {
+		percent := math.Round(float64(*entry.Page)/float64(book.PageCount)*1000) / 10
+		entry.Percent = &percent
+	}
+
+	if entry.LoggedAt.IsZero() {
+		entry.LoggedAt = time.Now()
+	}
+	entry.BookID = bookID
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO reading_progress (book_id, page, percent, location, logged_at) VALUES (?, ?, ?, ?, ?)`,
+		bookID, entry.Page, entry.Percent, entry.Location, entry.LoggedAt.Format(time.RFC3339),
+	)
+	if err != nil {
+		return models.ProgressUpdate{}, fmt.Errorf("failed to log progress: %w", translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.ProgressUpdate{}, fmt.Errorf("failed to read progress ID: %w", translateError(err))
+	}
+	entry.ID = int(id)
+
+	update := models.ProgressUpdate{Progress: entry}
+
+	if book.Status == models.StatusToRead {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE books SET status = ?, version = version + 1 WHERE id = ?`,
+			models.StatusReading, bookID,
+		)
+		if err != nil {
+			return models.ProgressUpdate{}, fmt.Errorf("failed to start book %d: %w", bookID, translateError(err))
+		}
+		update.StatusChanged = true
+
+		book.Status = models.StatusReading
+		if err := syncReadings(ctx, tx, models.StatusToRead, *book); err != nil {
+			return models.ProgressUpdate{}, err
+		}
+
+		if book, err = getBook(ctx, tx, bookID); err != nil {
+			return models.ProgressUpdate{}, err
+		}
+	}
+	update.Book = *book
+
+	reachedEnd := (entry.Page != nil && book.PageCount > 0 && *entry.Page >= book.PageCount) ||
+		(entry.Percent != nil && *entry.Percent >= 100)
+	update.SuggestFinish = reachedEnd && book.Status != models.StatusFinished
+
+	if err := tx.Commit(); err != nil {
+		return models.ProgressUpdate{}, fmt.Errorf("failed to commit progress: %w", translateError(err))
+	}
+	return update, nil
+}
+
+// GetProgress returns a book's progress history, newest first
+func (s *SQLiteStore) GetProgress(ctx context.Context, bookID int) ([]models.ReadingProgress, error) {
+	if _, err := s.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, book_id, page, percent, COALESCE(location, ''), logged_at
+		FROM reading_progress
+		WHERE book_id = ?
+		ORDER BY logged_at DESC, id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query progress of book %d: %w", bookID, translateError(err))
+	}
+	defer rows.Close()
+
+	history := []models.ReadingProgress{}
+	for rows.Next() {
+		var entry models.ReadingProgress
+		var loggedAt string
+		err := rows.Scan(&entry.ID, &entry.BookID, &entry.Page, &entry.Percent, &entry.Location, &loggedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan progress: %w", err)
+		}
+		entry.LoggedAt, _ = time.Parse(time.RFC3339, loggedAt)
+		history = append(history, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read progress: %w", translateError(err))
+	}
+	return history, nil
+}
@@ -0,0 +1,280 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// readingColumns is the select list scanReading expects
+const readingColumns = `id, book_id, start_date, end_date, outcome, rating`
+
+// syncReadings opens and closes read-throughs to follow a status change
+// from one status to book.Status. from is empty for a new book.
+func syncReadings(ctx context.Context, q querier, from models.BookStatus, book models.Book) error {
+	openID, err := openReadingID(ctx, q, book.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	switch book.Status {
+	case models.StatusReading:
+		if openID != 0 {
+			return nil
+		}
+		// A new book keeps its own start date; otherwise this is a
+		// fresh start or a re-read beginning now
+		start := now
+		if from == "" {
+			start = book.StartDate
+		}
+		return insertReading(ctx, q, book.ID, start, nil, "")
+
+	case models.StatusFinished, models.StatusAbandoned:
+		end := now
+		if book.EndDate != nil {
+			end = *book.EndDate
+		}
+		outcome := models.ReadingOutcome(book.Status)
+
+		switch {
+		case openID != 0:
+			return closeReading(ctx, q, openID, end, outcome)
+		case from == "" || from == models.StatusToRead:
+			// Finished without ever being marked as reading
+			return insertReading(ctx, q, book.ID, book.StartDate, &end, outcome)
+		case from != book.Status:
+			// Switching between finished and abandoned corrects the last outcome
+			_, err := q.ExecContext(ctx,
+				`UPDATE readings SET outcome = ?
+				WHERE id = (SELECT id FROM readings WHERE book_id = ? ORDER BY start_date DESC, id DESC LIMIT 1)`,
+				outcome, book.ID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to update reading of book %d: %w", book.ID, translateError(err))
+			}
+		}
+
+	case models.StatusToRead:
+		// Putting a book back on the pile ends the read-through unfinished
+		if openID != 0 {
+			return closeReading(ctx, q, openID, now, models.OutcomeAbandoned)
+		}
+	}
+
+	return nil
+}
+
+// openReadingID returns the ID of the book's read-through in progress, or 0
+func openReadingID(ctx context.Context, q querier, bookID int) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx,
+		`SELECT id FROM readings WHERE book_id = ? AND end_date IS NULL`, bookID,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find open reading of book %d: %w", bookID, translateError(err))
+	}
+	return id, nil
+}
+
+// insertReading starts a read-through, or records a finished one when end is set
+func insertReading(ctx context.Context, q querier, bookID int, start time.Time, end *time.Time, outcome models.ReadingOutcome) error {
+	var endDate, outcomeValue interface{}
+	if end != nil {
+		endDate = end.Format(time.RFC3339)
+		outcomeValue = outcome
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO readings (book_id, start_date, end_date, outcome) VALUES (?, ?, ?, ?)`,
+		bookID, start.Format(time.RFC3339), endDate, outcomeValue,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start reading of book %d: %w", bookID, translateError(err))
+	}
+	return nil
+}
+
+// closeReading ends a read-through with the given outcome
+func closeReading(ctx context.Context, q querier, id int, end time.Time, outcome models.ReadingOutcome) error {
+	_, err := q.ExecContext(ctx,
+		`UPDATE readings SET end_date = ?, outcome = ? WHERE id = ?`,
+		end.Format(time.RFC3339), outcome, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to close reading %d: %w", id, translateError(err))
+	}
+	return nil
+}
+
+// GetReadings returns a book's read-throughs, oldest first
+func (s *SQLiteStore) GetReadings(ctx context.Context, bookID int) ([]models.Reading, error) {
+	book, err := s.GetByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	return book.Readings, nil
+}
+
+// UpdateReading corrects the dates, outcome or rating of a read-through
+// and bumps the book's version
+func (s *SQLiteStore) UpdateReading(ctx context.Context, bookID int, reading models.Reading) (models.Reading, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Reading{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	var endDate, outcome interface{}
+	if reading.EndDate != nil {
+		endDate = reading.EndDate.Format(time.RFC3339)
+		outcome = reading.Outcome
+	} else {
+		openID, err := openReadingID(ctx, tx, bookID)
+		if err != nil {
+			return models.Reading{}, err
+		}
+		if openID != 0 && openID != reading.ID {
+			return models.Reading{}, fmt.Errorf("reading %d is already in progress: %w", openID, models.ErrConflict)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE readings SET start_date = ?, end_date = ?, outcome = ?, rating = ?
+		WHERE id = ? AND book_id = ?`,
+		reading.StartDate.Format(time.RFC3339), endDate, outcome, reading.Rating, reading.ID, bookID,
+	)
+	if err != nil {
+		return models.Reading{}, fmt.Errorf("failed to update reading %d: %w", reading.ID, translateError(err))
+	}
+	if err := changedReading(ctx, tx, bookID, reading.ID, result); err != nil {
+		return models.Reading{}, err
+	}
+
+	updated, err := scanReading(tx.QueryRowContext(ctx,
+		`SELECT `+readingColumns+` FROM readings WHERE id = ?`, reading.ID,
+	))
+	if err != nil {
+		return models.Reading{}, fmt.Errorf("failed to read reading %d: %w", reading.ID, translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Reading{}, fmt.Errorf("failed to commit reading: %w", translateError(err))
+	}
+	return updated, nil
+}
+
+// DeleteReading removes a read-through and bumps the book's version
+func (s *SQLiteStore) DeleteReading(ctx context.Context, bookID, readingID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM readings WHERE id = ? AND book_id = ?`, readingID, bookID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete reading %d: %w", readingID, translateError(err))
+	}
+	if err := changedReading(ctx, tx, bookID, readingID, result); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reading: %w", translateError(err))
+	}
+	return nil
+}
+
+// changedReading checks that a write hit the read-through and bumps the
+// book's version so its ETag changes
+func changedReading(ctx context.Context, q querier, bookID, readingID int, result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to change reading %d: %w", readingID, translateError(err))
+	}
+	if n == 0 {
+		if _, err := getBook(ctx, q, bookID); err != nil {
+			return err
+		}
+		return fmt.Errorf("reading %d %w", readingID, models.ErrNotFound)
+	}
+
+	return touchBook(ctx, q, bookID)
+}
+
+// scanReading scans a row selected with readingColumns
+func scanReading(row rowScanner) (models.Reading, error) {
+	var reading models.Reading
+	var startDate string
+	var endDate, outcome sql.NullString
+	var rating sql.NullFloat64
+
+	if err := row.Scan(&reading.ID, &reading.BookID, &startDate, &endDate, &outcome, &rating); err != nil {
+		return reading, err
+	}
+
+	reading.StartDate, _ = time.Parse(time.RFC3339, startDate)
+	if endDate.Valid {
+		end, _ := time.Parse(time.RFC3339, endDate.String)
+		reading.EndDate = &end
+	}
+	reading.Outcome = models.ReadingOutcome(outcome.String)
+	if rating.Valid {
+		reading.Rating = &rating.Float64
+	}
+
+	return reading, nil
+}
+
+// loadReadings fills in the read-throughs of each book with one query
+func loadReadings(ctx context.Context, q querier, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(books))
+	args := make([]interface{}, len(books))
+	for i := range books {
+		index[books[i].ID] = i
+		args[i] = books[i].ID
+		books[i].Readings = []models.Reading{}
+	}
+
+	query := `
+		SELECT ` + readingColumns + `
+		FROM readings
+		WHERE book_id IN (` + placeholders(len(books)) + `)
+		ORDER BY start_date, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load readings: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reading, err := scanReading(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan reading: %w", err)
+		}
+		i := index[reading.BookID]
+		books[i].Readings = append(books[i].Readings, reading)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read readings: %w", translateError(err))
+	}
+	return nil
+}
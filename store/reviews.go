@@ -0,0 +1,222 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// reviewSelectColumns lists the reviews columns scanReview reads, in order
+var reviewSelectColumns = []string{
+	"id", "book_id", "reading_id", "body", "spoiler", "created_at", "updated_at",
+}
+
+// CreateReview adds a review to a book, or to one of its read-throughs
+// when ReadingID is set. Each of them can only have one review.
+func (s *SQLiteStore) CreateReview(ctx context.Context, bookID int, review models.Review) (models.Review, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Review{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if _, err := getBook(ctx, tx, bookID); err != nil {
+		return models.Review{}, err
+	}
+	if err := checkReviewTarget(ctx, tx, bookID, 0, review.ReadingID); err != nil {
+		return models.Review{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	review.BookID = bookID
+	review.CreatedAt, review.UpdatedAt = now, now
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO reviews (book_id, reading_id, body, spoiler, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		bookID, review.ReadingID, review.Body, review.Spoiler,
+		now.Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return models.Review{}, fmt.Errorf("failed to save review: %w", translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Review{}, fmt.Errorf("failed to read review ID: %w", translateError(err))
+	}
+	review.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return models.Review{}, fmt.Errorf("failed to commit review: %w", translateError(err))
+	}
+	return review, nil
+}
+
+// GetReviews returns a book's reviews, the whole-book review first and
+// then read-through reviews in reading order
+func (s *SQLiteStore) GetReviews(ctx context.Context, bookID int) ([]models.Review, error) {
+	if _, err := s.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + selectReviewColumns("rv") + `
+		FROM reviews rv
+		LEFT JOIN readings rd ON rd.id = rv.reading_id
+		WHERE rv.book_id = ?
+		ORDER BY rv.reading_id IS NOT NULL, rd.start_date, rv.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviews of book %d: %w", bookID, translateError(err))
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reviews: %w", translateError(err))
+	}
+	return reviews, nil
+}
+
+// UpdateReview replaces the body, spoiler flag and read-through of a review
+func (s *SQLiteStore) UpdateReview(ctx context.Context, bookID int, review models.Review) (models.Review, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Review{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := checkReviewTarget(ctx, tx, bookID, review.ID, review.ReadingID); err != nil {
+		return models.Review{}, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE reviews SET reading_id = ?, body = ?, spoiler = ?, updated_at = ?
+		WHERE id = ? AND book_id = ?`,
+		review.ReadingID, review.Body, review.Spoiler,
+		time.Now().UTC().Format(time.RFC3339), review.ID, bookID,
+	)
+	if err != nil {
+		return models.Review{}, fmt.Errorf("failed to update review %d: %w", review.ID, translateError(err))
+	}
+	if err := reviewChanged(ctx, tx, bookID, review.ID, result); err != nil {
+		return models.Review{}, err
+	}
+
+	updated, err := scanReview(tx.QueryRowContext(ctx,
+		`SELECT `+selectReviewColumns("")+` FROM reviews WHERE id = ?`, review.ID,
+	))
+	if err != nil {
+		return models.Review{}, fmt.Errorf("failed to read review %d: %w", review.ID, translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Review{}, fmt.Errorf("failed to commit review: %w", translateError(err))
+	}
+	return updated, nil
+}
+
+// DeleteReview removes a review from a book
+func (s *SQLiteStore) DeleteReview(ctx context.Context, bookID, reviewID int) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM reviews WHERE id = ? AND book_id = ?`, reviewID, bookID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete review %d: %w", reviewID, translateError(err))
+	}
+	return reviewChanged(ctx, s.db, bookID, reviewID, result)
+}
+
+// checkReviewTarget makes sure readingID is a read-through of the book
+// and that no other review (besides reviewID) already covers it
+func checkReviewTarget(ctx context.Context, q querier, bookID, reviewID int, readingID *int) error {
+	if readingID != nil {
+		var exists int
+		err := q.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM readings WHERE id = ? AND book_id = ?`, *readingID, bookID,
+		).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check reading %d: %w", *readingID, translateError(err))
+		}
+		if exists == 0 {
+			return models.NewValidationError("ReadingID", fmt.Sprintf("reading %d is not a read-through of this book", *readingID))
+		}
+	}
+
+	var existing int
+	err := q.QueryRowContext(ctx,
+		`SELECT id FROM reviews WHERE book_id = ? AND reading_id IS ? AND id != ?`,
+		bookID, readingID, reviewID,
+	).Scan(&existing)
+	if err == nil {
+		if readingID == nil {
+			return fmt.Errorf("book already has review %d: %w", existing, models.ErrConflict)
+		}
+		return fmt.Errorf("reading %d already has review %d: %w", *readingID, existing, models.ErrConflict)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check existing reviews: %w", translateError(err))
+	}
+	return nil
+}
+
+// reviewChanged reports whether a write hit the review, telling a missing
+// book apart from a missing review
+func reviewChanged(ctx context.Context, q querier, bookID, reviewID int, result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to change review %d: %w", reviewID, translateError(err))
+	}
+	if n == 0 {
+		if _, err := getBook(ctx, q, bookID); err != nil {
+			return err
+		}
+		return fmt.Errorf("review %d %w", reviewID, models.ErrNotFound)
+	}
+	return nil
+}
+
+// selectReviewColumns renders reviewSelectColumns for a SELECT, qualified
+// with a table alias when one is given
+func selectReviewColumns(alias string) string {
+	if alias == "" {
+		return strings.Join(reviewSelectColumns, ", ")
+	}
+	return alias + "." + strings.Join(reviewSelectColumns, ", "+alias+".")
+}
+
+// scanReview scans a row selected with selectReviewColumns
+func scanReview(row rowScanner) (models.Review, error) {
+	var review models.Review
+	var readingID sql.NullInt64
+	var createdAt, updatedAt string
+
+	err := row.Scan(&review.ID, &review.BookID, &readingID, &review.Body, &review.Spoiler, &createdAt, &updatedAt)
+	if err != nil {
+		return review, err
+	}
+
+	if readingID.Valid {
+		id := int(readingID.Int64)
+		review.ReadingID = &id
+	}
+	review.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	review.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+	return review, nil
+}
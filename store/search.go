@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/favxlaw/models"
+)
+
+// Search runs a full-text query over title, author and notes, best match
+// first. Title hits weigh more than author hits, which weigh more than notes.
+func (s *SQLiteStore) Search(ctx context.Context, q string, limit int) ([]models.SearchResult, error) {
+	match, err := buildMatchQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + selectBookColumns("b") + `,
+			` + snippetColumn("books_fts", 2, 16) + `,
+			bm25(books_fts, 10.0, 5.0, 1.0) AS rank
+		FROM books_fts
+		JOIN books b ON b.id = books_fts.rowid
+		WHERE books_fts MATCH ?
+		ORDER BY rank
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search books: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		var snippet sql.NullString
+
+		result.Book, err = scanBook(rows, &snippet, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Snippet = snippetHTML(snippet.String)
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", translateError(err))
+	}
+
+	books := make([]models.Book, len(results))
+	for i := range results {
+		books[i] = results[i].Book
+	}
+	if err := loadBookDetails(ctx, s.db, books); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Book = books[i]
+	}
+
+	return results, nil
+}
+
+// Private-use characters that snippet() puts around matches. The text
+// itself is escaped before they are swapped for <mark> tags, so stored
+// text can never smuggle markup into a snippet.
+const (
+	snippetOpen  = "\uE000"
+	snippetClose = "\uE001"
+)
+
+// snippetColumn is the SQL for an excerpt of column of an FTS5 table
+// with at most tokens tokens; pass the result through snippetHTML
+func snippetColumn(table string, column, tokens int) string {
+	return fmt.Sprintf("snippet(%s, %d, '%s', '%s', '…', %d)", table, column, snippetOpen, snippetClose, tokens)
+}
+
+// snippetHTML escapes a snippet and marks up its matches with <mark> tags
+func snippetHTML(snippet string) string {
+	return strings.NewReplacer(snippetOpen, "<mark>", snippetClose, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// buildMatchQuery turns user input into a safe FTS5 MATCH expression.
+// Every word and "quoted phrase" must match; a trailing * makes it a
+// prefix search. Other FTS5 syntax is treated as plain text, so user
+// input can never produce a query syntax error.
+func buildMatchQuery(q string) (string, error) {
+	var terms []string
+
+	input := strings.TrimSpace(q)
+	for input != "" {
+		var term string
+		if input[0] == '"' {
+			end := strings.IndexByte(input[1:], '"')
+			if end < 0 {
+				term, input = input[1:], ""
+			} else {
+				term, input = input[1:end+1], input[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(input, " \t\n\"")
+			if end < 0 {
+				end = len(input)
+			}
+			term, input = input[:end], input[end:]
+		}
+
+		prefix := false
+		if strings.HasPrefix(input, "*") {
+			prefix, input = true, input[1:]
+		} else if strings.HasSuffix(term, "*") {
+			prefix, term = true, strings.TrimRight(term, "*")
+		}
+		input = strings.TrimSpace(input)
+
+		term = strings.TrimSpace(strings.ReplaceAll(term, `"`, ""))
+		if term == "" {
+			continue
+		}
+
+		quoted := `"` + term + `"`
+		if prefix {
+			quoted += "*"
+		}
+		terms = append(terms, quoted)
+	}
+
+	if len(terms) == 0 {
+		return "", models.NewValidationError("q", "search query must contain at least one word")
+	}
+
+	return strings.Join(terms, " "), nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+func TestSnippetHTML(t *testing.T) {
+	match := func(s string) string { return snippetOpen + s + snippetClose }
+
+	tests := []struct {
+		snippet string
+		want    string
+	}{
+		{"plain text", "plain text"},
+		{"the " + match("spice") + " must flow", "the <mark>spice</mark> must flow"},
+		{"…" + match("a") + " & " + match("b") + "…", "…<mark>a</mark> &amp; <mark>b</mark>…"},
+		{`<img src=x onerror="alert(1)"> ` + match("x"), `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>x</mark>`},
+		{"<mark>fake</mark> " + match("real"), "&lt;mark&gt;fake&lt;/mark&gt; <mark>real</mark>"},
+		{"it's", "it&#39;s"},
+	}
+
+	for _, tt := range tests {
+		if got := snippetHTML(tt.snippet); got != tt.want {
+			t.Errorf("snippetHTML(%q) = %q, want %q", tt.snippet, got, tt.want)
+		}
+	}
+}
+
+func TestSearchEscapesSnippet(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, models.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: models.StatusReading, StartDate: time.Now(),
+		Notes: `Spice <img src=x onerror="alert(1)"> flows`,
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+
+	results, err := s.Search(ctx, "spice", 10)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Search returned %d results, want 1", len(results))
+	}
+
+	want := `<mark>Spice</mark> &lt;img src=x onerror=&#34;alert(1)&#34;&gt; flows`
+	if results[0].Snippet != want {
+		t.Errorf("Snippet = %q, want %q", results[0].Snippet, want)
+	}
+}
+
+func TestSearchHighlightsEscapesSnippet(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	book, err := s.Create(ctx, models.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: models.StatusReading, StartDate: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	_, err = s.CreateHighlight(ctx, book.ID, models.Highlight{Text: `Fear is the <script>alert(1)</script> mind-killer`})
+	if err != nil {
+		t.Fatalf("CreateHighlight error: %v", err)
+	}
+
+	results, err := s.SearchHighlights(ctx, "fear", 10)
+	if err != nil {
+		t.Fatalf("SearchHighlights error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("SearchHighlights returned %d results, want 1", len(results))
+	}
+
+	want := `<mark>Fear</mark> is the &lt;script&gt;alert(1)&lt;/script&gt; mind-killer`
+	if results[0].Snippet != want {
+		t.Errorf("Snippet = %q, want %q", results[0].Snippet, want)
+	}
+}
@@ -0,0 +1,283 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/favxlaw/models"
+)
+
+// errSeriesNotFound is returned when no series matches the requested ID
+var errSeriesNotFound = fmt.Errorf("series %w", models.ErrNotFound)
+
+// seriesQuery selects series with their book counts; callers append
+// WHERE conditions before seriesGroupBy
+const seriesQuery = `
+	SELECT sr.id, sr.name, sr.description, COUNT(b.id)
+	FROM series sr
+	LEFT JOIN books b ON b.series_id = sr.id
+`
+
+const seriesGroupBy = ` GROUP BY sr.id`
+
+// ListSeries returns every series by name
+func (s *SQLiteStore) ListSeries(ctx context.Context) ([]models.Series, error) {
+	rows, err := s.db.QueryContext(ctx, seriesQuery+seriesGroupBy+` ORDER BY sr.name COLLATE NOCASE, sr.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query series: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	list := []models.Series{}
+	for rows.Next() {
+		series, err := scanSeries(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan series: %w", err)
+		}
+		list = append(list, series)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read series: %w", translateError(err))
+	}
+	return list, nil
+}
+
+// GetSeries finds a series by ID and lists its books in reading order.
+// Unnumbered books come last.
+func (s *SQLiteStore) GetSeries(ctx context.Context, id int) (*models.SeriesDetail, error) {
+	series, err := getSeries(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + selectBookColumns("") + `
+		FROM books
+		WHERE series_id = ?
+		ORDER BY series_position IS NULL, series_position, publication_year, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query books of series %d: %w", id, translateError(err))
+	}
+	defer rows.Close()
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadBookDetails(ctx, s.db, books); err != nil {
+		return nil, err
+	}
+
+	return &models.SeriesDetail{Series: *series, Books: books}, nil
+}
+
+// getSeries finds a series by ID using q
+func getSeries(ctx context.Context, q querier, id int) (*models.Series, error) {
+	series, err := scanSeries(q.QueryRowContext(ctx, seriesQuery+` WHERE sr.id = ?`+seriesGroupBy, id))
+	if err == sql.ErrNoRows {
+		return nil, errSeriesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series %d: %w", id, translateError(err))
+	}
+	return &series, nil
+}
+
+// ensureSeries returns the series called name, ignoring case, creating
+// it if needed
+func ensureSeries(ctx context.Context, q querier, name string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, models.NewValidationError("SeriesName", "series name is required")
+	}
+
+	var id int
+	err := q.QueryRowContext(ctx,
+		`SELECT id FROM series WHERE name = ? COLLATE NOCASE ORDER BY id LIMIT 1`, name,
+	).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to find series: %w", translateError(err))
+	}
+
+	result, err := q.ExecContext(ctx, `INSERT INTO series (name) VALUES (?)`, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert series: %w", translateError(err))
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read new series ID: %w", translateError(err))
+	}
+	return int(newID), nil
+}
+
+// loadSeriesNames fills in the SeriesName of each book with one query
+func loadSeriesNames(ctx context.Context, q querier, books []models.Book) error {
+	var args []interface{}
+	seen := map[int]bool{}
+	for i := range books {
+		books[i].SeriesName = ""
+		if id := books[i].SeriesID; id != nil && !seen[*id] {
+			seen[*id] = true
+			args = append(args, *id)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, name FROM series WHERE id IN (`+placeholders(len(args))+`)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load series: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	names := make(map[int]string, len(args))
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return fmt.Errorf("failed to scan series: %w", err)
+		}
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read series: %w", translateError(err))
+	}
+
+	for i := range books {
+		if id := books[i].SeriesID; id != nil {
+			books[i].SeriesName = names[*id]
+		}
+	}
+	return nil
+}
+
+// CreateSeries adds an empty series
+func (s *SQLiteStore) CreateSeries(ctx context.Context, series models.Series) (models.Series, error) {
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO series (name, description) VALUES (?, ?)`, series.Name, series.Description,
+	)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("failed to insert series: %w", translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Series{}, fmt.Errorf("failed to read new series ID: %w", translateError(err))
+	}
+
+	series.ID = int(id)
+	series.BookCount = 0
+	return series, nil
+}
+
+// UpdateSeries replaces the name and description of a series. Books show
+// the series name, so renaming a series bumps the versions of its books.
+func (s *SQLiteStore) UpdateSeries(ctx context.Context, series models.Series) (*models.Series, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRowContext(ctx, `SELECT name FROM series WHERE id = ?`, series.ID).Scan(&oldName)
+	if err == sql.ErrNoRows {
+		return nil, errSeriesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read series %d: %w", series.ID, translateError(err))
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE series SET name = ?, description = ? WHERE id = ?`,
+		series.Name, series.Description, series.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update series %d: %w", series.ID, translateError(err))
+	}
+
+	if series.Name != oldName {
+		_, err = tx.ExecContext(ctx, `UPDATE books SET version = version + 1 WHERE series_id = ?`, series.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update books of series %d: %w", series.ID, translateError(err))
+		}
+	}
+
+	updated, err := getSeries(ctx, tx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit series %d: %w", series.ID, translateError(err))
+	}
+	return updated, nil
+}
+
+// DeleteSeries removes a series. Its books stay in the library but lose
+// their series and position, which bumps their versions.
+func (s *SQLiteStore) DeleteSeries(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE books SET series_id = NULL, series_position = NULL, version = version + 1 WHERE series_id = ?`, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to detach books from series %d: %w", id, translateError(err))
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM series WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete series %d: %w", id, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete series %d: %w", id, translateError(err))
+	} else if n == 0 {
+		return errSeriesNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit series %d: %w", id, translateError(err))
+	}
+	return nil
+}
+
+// checkSeries makes sure a book joins a series that exists
+func checkSeries(ctx context.Context, q querier, seriesID *int) error {
+	if seriesID == nil {
+		return nil
+	}
+
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM series WHERE id = ?)`, *seriesID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check series %d: %w", *seriesID, translateError(err))
+	}
+	if !exists {
+		return models.NewValidationError("SeriesID", fmt.Sprintf("series %d does not exist", *seriesID))
+	}
+	return nil
+}
+
+// scanSeries scans a row of seriesQuery
+func scanSeries(row rowScanner) (models.Series, error) {
+	var series models.Series
+	err := row.Scan(&series.ID, &series.Name, &series.Description, &series.BookCount)
+	return series, err
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+func TestUpdateSeriesBumpsBookVersions(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	series, err := s.CreateSeries(ctx, models.Series{Name: "Dune"})
+	if err != nil {
+		t.Fatalf("CreateSeries error: %v", err)
+	}
+	member, err := s.Create(ctx, models.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: models.StatusToRead, StartDate: time.Now(), SeriesID: &series.ID,
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	other, err := s.Create(ctx, models.Book{
+		Title: "Neuromancer", Author: "William Gibson", Status: models.StatusToRead, StartDate: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+
+	version := func(id int) int {
+		book, err := s.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID error: %v", err)
+		}
+		return book.Version
+	}
+
+	steps := []struct {
+		name    string
+		update  models.Series
+		bumped  bool
+		display string
+	}{
+		{"description only", models.Series{ID: series.ID, Name: "Dune", Description: "Arrakis"}, false, "Dune"},
+		{"rename", models.Series{ID: series.ID, Name: "Dune Chronicles", Description: "Arrakis"}, true, "Dune Chronicles"},
+	}
+
+	for _, step := range steps {
+		memberBefore, otherBefore := version(member.ID), version(other.ID)
+		if _, err := s.UpdateSeries(ctx, step.update); err != nil {
+			t.Fatalf("%s: UpdateSeries error: %v", step.name, err)
+		}
+
+		if bumped := version(member.ID) != memberBefore; bumped != step.bumped {
+			t.Errorf("%s: member version bumped = %v, want %v", step.name, bumped, step.bumped)
+		}
+		if version(other.ID) != otherBefore {
+			t.Errorf("%s: version of a book outside the series changed", step.name)
+		}
+		if book, _ := s.GetByID(ctx, member.ID); book.SeriesName != step.display {
+			t.Errorf("%s: SeriesName = %q, want %q", step.name, book.SeriesName, step.display)
+		}
+	}
+
+	if _, err := s.UpdateSeries(ctx, models.Series{ID: series.ID + 100, Name: "Missing"}); err != errSeriesNotFound {
+		t.Errorf("UpdateSeries of a missing series error = %v, want %v", err, errSeriesNotFound)
+	}
+}
@@ -0,0 +1,287 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/favxlaw/models"
+)
+
+// sessionColumns is the select list scanSession expects
+const sessionColumns = `id, book_id, reader, started_at, ended_at,
+	CAST(ROUND((julianday(ended_at) - julianday(started_at)) * 86400) AS INTEGER),
+	pages_read, note`
+
+// sessionTime formats session timestamps in UTC so they compare correctly as text
+func sessionTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// StartSession opens a reading session for reader on a book. A reader
+// with a session already running, on any book, gets a conflict.
+func (s *SQLiteStore) StartSession(ctx context.Context, bookID int, reader, note string) (models.ReadingSession, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if _, err := getBook(ctx, tx, bookID); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	var openBookID int
+	err = tx.QueryRowContext(ctx,
+		`SELECT book_id FROM reading_sessions WHERE reader = ? AND ended_at IS NULL`,
+		reader,
+	).Scan(&openBookID)
+	if err == nil {
+		return models.ReadingSession{}, fmt.Errorf("a session is already running on book %d: %w", openBookID, models.ErrConflict)
+	}
+	if err != sql.ErrNoRows {
+		return models.ReadingSession{}, fmt.Errorf("failed to check open sessions: %w", translateError(err))
+	}
+
+	session := models.ReadingSession{
+		BookID:    bookID,
+		Reader:    reader,
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+		Note:      note,
+	}
+
+	if err := insertSession(ctx, tx, &session); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to commit session: %w", translateError(err))
+	}
+	return session, nil
+}
+
+// StopSession ends the reader's running session on a book
+func (s *SQLiteStore) StopSession(ctx context.Context, bookID int, reader string, pagesRead int, note string) (models.ReadingSession, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx,
+		`SELECT id FROM reading_sessions WHERE book_id = ? AND reader = ? AND ended_at IS NULL`,
+		bookID, reader,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.ReadingSession{}, fmt.Errorf("running session on book %d %w", bookID, models.ErrNotFound)
+	}
+	if err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to find running session: %w", translateError(err))
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE reading_sessions
+		SET ended_at = ?, pages_read = ?, note = CASE WHEN ? = '' THEN note ELSE ? END
+		WHERE id = ?`,
+		sessionTime(time.Now()), pagesRead, note, note, id,
+	)
+	if err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to stop session %d: %w", id, translateError(err))
+	}
+
+	if err := touchBook(ctx, tx, bookID); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	session, err := scanSession(tx.QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM reading_sessions WHERE id = ?`, id,
+	))
+	if err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to read session %d: %w", id, translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to commit session: %w", translateError(err))
+	}
+	return session, nil
+}
+
+// LogSession records a finished session after the fact. It must not
+// overlap any other session of the same reader.
+func (s *SQLiteStore) LogSession(ctx context.Context, bookID int, session models.ReadingSession) (models.ReadingSession, error) {
+	if session.EndedAt == nil {
+		return models.ReadingSession{}, models.NewValidationError("EndedAt", "EndedAt is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if _, err := getBook(ctx, tx, bookID); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	var overlapping int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM reading_sessions
+		WHERE reader = ? AND started_at < ? AND (ended_at IS NULL OR ended_at > ?)`,
+		session.Reader, sessionTime(*session.EndedAt), sessionTime(session.StartedAt),
+	).Scan(&overlapping)
+	if err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to check overlapping sessions: %w", translateError(err))
+	}
+	if overlapping > 0 {
+		return models.ReadingSession{}, fmt.Errorf("session overlaps another session of this reader: %w", models.ErrConflict)
+	}
+
+	session.BookID = bookID
+	if err := insertSession(ctx, tx, &session); err != nil {
+		return models.ReadingSession{}, err
+	}
+	if err := touchBook(ctx, tx, bookID); err != nil {
+		return models.ReadingSession{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ReadingSession{}, fmt.Errorf("failed to commit session: %w", translateError(err))
+	}
+	return session, nil
+}
+
+// GetSessions returns a book's reading sessions, most recent first
+func (s *SQLiteStore) GetSessions(ctx context.Context, bookID int) ([]models.ReadingSession, error) {
+	if _, err := s.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+sessionColumns+` FROM reading_sessions WHERE book_id = ? ORDER BY started_at DESC, id DESC`,
+		bookID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions of book %d: %w", bookID, translateError(err))
+	}
+	defer rows.Close()
+
+	sessions := []models.ReadingSession{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", translateError(err))
+	}
+	return sessions, nil
+}
+
+// insertSession saves a new session and fills in its ID and duration
+func insertSession(ctx context.Context, q querier, session *models.ReadingSession) error {
+	var endedAt interface{}
+	if session.EndedAt != nil {
+		endedAt = sessionTime(*session.EndedAt)
+		session.DurationSeconds = int64(session.EndedAt.Sub(session.StartedAt).Seconds())
+	}
+
+	result, err := q.ExecContext(ctx,
+		`INSERT INTO reading_sessions (book_id, reader, started_at, ended_at, pages_read, note)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		session.BookID, session.Reader, sessionTime(session.StartedAt), endedAt, session.PagesRead, session.Note,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to read session ID: %w", translateError(err))
+	}
+	session.ID = int(id)
+	return nil
+}
+
+// scanSession scans a row selected with sessionColumns
+func scanSession(row rowScanner) (models.ReadingSession, error) {
+	var session models.ReadingSession
+	var startedAt string
+	var endedAt sql.NullString
+	var duration sql.NullInt64
+
+	err := row.Scan(
+		&session.ID,
+		&session.BookID,
+		&session.Reader,
+		&startedAt,
+		&endedAt,
+		&duration,
+		&session.PagesRead,
+		&session.Note,
+	)
+	if err != nil {
+		return session, err
+	}
+
+	session.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+	if endedAt.Valid {
+		ended, _ := time.Parse(time.RFC3339, endedAt.String)
+		session.EndedAt = &ended
+	}
+	session.DurationSeconds = duration.Int64
+
+	return session, nil
+}
+
+// loadSessionStats fills in the Sessions summary of each book with one query
+func loadSessionStats(ctx context.Context, q querier, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(books))
+	args := make([]interface{}, len(books))
+	for i := range books {
+		index[books[i].ID] = i
+		args[i] = books[i].ID
+		books[i].Sessions = models.SessionStats{}
+	}
+
+	query := `
+		SELECT book_id, COUNT(*),
+			CAST(ROUND(SUM(julianday(ended_at) - julianday(started_at)) * 86400) AS INTEGER),
+			SUM(pages_read), MAX(ended_at)
+		FROM reading_sessions
+		WHERE ended_at IS NOT NULL AND book_id IN (` + placeholders(len(books)) + `)
+		GROUP BY book_id
+	`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load session stats: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var stats models.SessionStats
+		var lastReadAt string
+		if err := rows.Scan(&bookID, &stats.Count, &stats.TotalSeconds, &stats.PagesRead, &lastReadAt); err != nil {
+			return fmt.Errorf("failed to scan session stats: %w", err)
+		}
+
+		last, _ := time.Parse(time.RFC3339, lastReadAt)
+		stats.LastReadAt = &last
+		books[index[bookID]].Sessions = stats
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read session stats: %w", translateError(err))
+	}
+	return nil
+}
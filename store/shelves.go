@@ -0,0 +1,380 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/favxlaw/models"
+)
+
+// errShelfNotFound is returned when no shelf matches the requested ID
+var errShelfNotFound = fmt.Errorf("shelf %w", models.ErrNotFound)
+
+// shelfQuery selects shelves with their book counts; callers append
+// WHERE conditions before shelfGroupBy
+const shelfQuery = `
+	SELECT s.id, s.name, s.description, s.visibility, COUNT(sb.book_id)
+	FROM shelves s
+	LEFT JOIN shelf_books sb ON sb.shelf_id = s.id
+`
+
+const shelfGroupBy = ` GROUP BY s.id`
+
+// ListShelves returns every shelf by name, only those with the given
+// visibility when it is set
+func (s *SQLiteStore) ListShelves(ctx context.Context, visibility models.ShelfVisibility) ([]models.Shelf, error) {
+	query := shelfQuery
+	args := []interface{}{}
+	if visibility != "" {
+		query += ` WHERE s.visibility = ?`
+		args = append(args, visibility)
+	}
+	query += shelfGroupBy + ` ORDER BY s.name COLLATE NOCASE`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shelves: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	shelves := []models.Shelf{}
+	for rows.Next() {
+		shelf, err := scanShelf(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shelf: %w", err)
+		}
+		shelves = append(shelves, shelf)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read shelves: %w", translateError(err))
+	}
+	return shelves, nil
+}
+
+// GetShelf finds a shelf by ID
+func (s *SQLiteStore) GetShelf(ctx context.Context, id int) (*models.Shelf, error) {
+	return getShelf(ctx, s.db, id)
+}
+
+// getShelf finds a shelf by ID using q
+func getShelf(ctx context.Context, q querier, id int) (*models.Shelf, error) {
+	shelf, err := scanShelf(q.QueryRowContext(ctx, shelfQuery+` WHERE s.id = ?`+shelfGroupBy, id))
+	if err == sql.ErrNoRows {
+		return nil, errShelfNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shelf %d: %w", id, translateError(err))
+	}
+	return &shelf, nil
+}
+
+// CreateShelf adds an empty shelf. Shelf names are unique, ignoring case.
+func (s *SQLiteStore) CreateShelf(ctx context.Context, shelf models.Shelf) (models.Shelf, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Shelf{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := checkShelfName(ctx, tx, 0, shelf.Name); err != nil {
+		return models.Shelf{}, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO shelves (name, description, visibility) VALUES (?, ?, ?)`,
+		shelf.Name, shelf.Description, shelf.Visibility,
+	)
+	if err != nil {
+		return models.Shelf{}, fmt.Errorf("failed to insert shelf: %w", translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Shelf{}, fmt.Errorf("failed to read new shelf ID: %w", translateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Shelf{}, fmt.Errorf("failed to commit shelf: %w", translateError(err))
+	}
+
+	shelf.ID = int(id)
+	shelf.BookCount = 0
+	return shelf, nil
+}
+
+// UpdateShelf replaces the name, description and visibility of a shelf
+func (s *SQLiteStore) UpdateShelf(ctx context.Context, shelf models.Shelf) (*models.Shelf, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := checkShelfName(ctx, tx, shelf.ID, shelf.Name); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE shelves SET name = ?, description = ?, visibility = ? WHERE id = ?`,
+		shelf.Name, shelf.Description, shelf.Visibility, shelf.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update shelf %d: %w", shelf.ID, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to update shelf %d: %w", shelf.ID, translateError(err))
+	} else if n == 0 {
+		return nil, errShelfNotFound
+	}
+
+	updated, err := getShelf(ctx, tx, shelf.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit shelf %d: %w", shelf.ID, translateError(err))
+	}
+	return updated, nil
+}
+
+// DeleteShelf removes a shelf. The books on it are not touched.
+func (s *SQLiteStore) DeleteShelf(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM shelves WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete shelf %d: %w", id, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete shelf %d: %w", id, translateError(err))
+	} else if n == 0 {
+		return errShelfNotFound
+	}
+	return nil
+}
+
+// checkShelfName rejects a name already used by another shelf
+func checkShelfName(ctx context.Context, q querier, id int, name string) error {
+	var existingID int
+	err := q.QueryRowContext(ctx,
+		`SELECT id FROM shelves WHERE name = ? COLLATE NOCASE`, name,
+	).Scan(&existingID)
+	if err == nil && existingID != id {
+		return fmt.Errorf("shelf %d is already named %q: %w", existingID, name, models.ErrConflict)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check shelf name: %w", translateError(err))
+	}
+	return nil
+}
+
+// GetShelfBooks returns the books on a shelf in shelf order
+func (s *SQLiteStore) GetShelfBooks(ctx context.Context, shelfID int) ([]models.ShelfBook, error) {
+	return shelfBooks(ctx, s.db, shelfID)
+}
+
+// shelfBooks reads the books on a shelf using q
+func shelfBooks(ctx context.Context, q querier, shelfID int) ([]models.ShelfBook, error) {
+	if _, err := getShelf(ctx, q, shelfID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + selectBookColumns("b") + `, sb.position
+		FROM shelf_books sb
+		JOIN books b ON b.id = sb.book_id
+		WHERE sb.shelf_id = ?
+		ORDER BY sb.position
+	`
+
+	rows, err := q.QueryContext(ctx, query, shelfID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query books of shelf %d: %w", shelfID, translateError(err))
+	}
+	defer rows.Close()
+
+	entries := []models.ShelfBook{}
+	for rows.Next() {
+		var entry models.ShelfBook
+		entry.Book, err = scanBook(rows, &entry.Position)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shelf book: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read shelf books: %w", translateError(err))
+	}
+	rows.Close()
+
+	books := make([]models.Book, len(entries))
+	for i := range entries {
+		books[i] = entries[i].Book
+	}
+	if err := loadBookDetails(ctx, q, books); err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Book = books[i]
+	}
+
+	return entries, nil
+}
+
+// AddShelfBook puts a book on a shelf at position, shifting the books
+// from there on down. A position of 0, or past the end, appends.
+func (s *SQLiteStore) AddShelfBook(ctx context.Context, shelfID, bookID, position int) ([]models.ShelfBook, error) {
+	return s.changeShelf(ctx, shelfID, func(tx *sql.Tx, count int) error {
+		if _, err := getBook(ctx, tx, bookID); err != nil {
+			return err
+		}
+
+		var onShelf int
+		err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM shelf_books WHERE shelf_id = ? AND book_id = ?`, shelfID, bookID,
+		).Scan(&onShelf)
+		if err != nil {
+			return fmt.Errorf("failed to check shelf %d: %w", shelfID, translateError(err))
+		}
+		if onShelf > 0 {
+			return fmt.Errorf("book %d is already on shelf %d: %w", bookID, shelfID, models.ErrConflict)
+		}
+
+		position = clampPosition(position, count+1)
+		_, err = tx.ExecContext(ctx,
+			`UPDATE shelf_books SET position = position + 1 WHERE shelf_id = ? AND position >= ?`,
+			shelfID, position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to make room on shelf %d: %w", shelfID, translateError(err))
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO shelf_books (shelf_id, book_id, position) VALUES (?, ?, ?)`,
+			shelfID, bookID, position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add book %d to shelf %d: %w", bookID, shelfID, translateError(err))
+		}
+		return nil
+	})
+}
+
+// MoveShelfBook moves a book to a new position on a shelf, shifting the
+// books in between
+func (s *SQLiteStore) MoveShelfBook(ctx context.Context, shelfID, bookID, position int) ([]models.ShelfBook, error) {
+	return s.changeShelf(ctx, shelfID, func(tx *sql.Tx, count int) error {
+		current, err := shelfPosition(ctx, tx, shelfID, bookID)
+		if err != nil {
+			return err
+		}
+
+		position = clampPosition(position, count)
+		switch {
+		case position < current:
+			_, err = tx.ExecContext(ctx,
+				`UPDATE shelf_books SET position = position + 1
+				WHERE shelf_id = ? AND position >= ? AND position < ?`,
+				shelfID, position, current,
+			)
+		case position > current:
+			_, err = tx.ExecContext(ctx,
+				`UPDATE shelf_books SET position = position - 1
+				WHERE shelf_id = ? AND position > ? AND position <= ?`,
+				shelfID, current, position,
+			)
+		default:
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reorder shelf %d: %w", shelfID, translateError(err))
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE shelf_books SET position = ? WHERE shelf_id = ? AND book_id = ?`,
+			position, shelfID, bookID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to move book %d on shelf %d: %w", bookID, shelfID, translateError(err))
+		}
+		return nil
+	})
+}
+
+// RemoveShelfBook takes a book off a shelf; the books after it move up
+func (s *SQLiteStore) RemoveShelfBook(ctx context.Context, shelfID, bookID int) ([]models.ShelfBook, error) {
+	return s.changeShelf(ctx, shelfID, func(tx *sql.Tx, count int) error {
+		if _, err := shelfPosition(ctx, tx, shelfID, bookID); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM shelf_books WHERE shelf_id = ? AND book_id = ?`, shelfID, bookID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to remove book %d from shelf %d: %w", bookID, shelfID, translateError(err))
+		}
+		return nil
+	})
+}
+
+// changeShelf runs a membership change on an existing shelf, passing the
+// number of books on it, and returns the shelf's books afterwards
+func (s *SQLiteStore) changeShelf(ctx context.Context, shelfID int, change func(tx *sql.Tx, count int) error) ([]models.ShelfBook, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	shelf, err := getShelf(ctx, tx, shelfID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := change(tx, shelf.BookCount); err != nil {
+		return nil, err
+	}
+
+	entries, err := shelfBooks(ctx, tx, shelfID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit shelf %d: %w", shelfID, translateError(err))
+	}
+	return entries, nil
+}
+
+// shelfPosition returns where a book sits on a shelf
+func shelfPosition(ctx context.Context, q querier, shelfID, bookID int) (int, error) {
+	var position int
+	err := q.QueryRowContext(ctx,
+		`SELECT position FROM shelf_books WHERE shelf_id = ? AND book_id = ?`, shelfID, bookID,
+	).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("book %d on shelf %d %w", bookID, shelfID, models.ErrNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find book %d on shelf %d: %w", bookID, shelfID, translateError(err))
+	}
+	return position, nil
+}
+
+// clampPosition keeps a requested position within 1..last; 0 means last
+func clampPosition(position, last int) int {
+	if position <= 0 || position > last {
+		return last
+	}
+	return position
+}
+
+// scanShelf scans a row selected with shelfQuery
+func scanShelf(row rowScanner) (models.Shelf, error) {
+	var shelf models.Shelf
+	err := row.Scan(&shelf.ID, &shelf.Name, &shelf.Description, &shelf.Visibility, &shelf.BookCount)
+	return shelf, err
+}
@@ -0,0 +1,283 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/favxlaw/filterql"
+	"github.com/favxlaw/models"
+)
+
+// errSmartShelfNotFound is returned when no smart shelf matches the requested ID
+var errSmartShelfNotFound = fmt.Errorf("smart shelf %w", models.ErrNotFound)
+
+// ListSmartShelves returns every smart shelf by name, each with how many
+// books it holds right now
+func (s *SQLiteStore) ListSmartShelves(ctx context.Context) ([]models.SmartShelf, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, description, filter, sort, tags, match_any_tag
+		FROM smart_shelves
+		ORDER BY name COLLATE NOCASE
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query smart shelves: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	shelves := []models.SmartShelf{}
+	for rows.Next() {
+		shelf, err := scanSmartShelf(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan smart shelf: %w", err)
+		}
+		shelves = append(shelves, shelf)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read smart shelves: %w", translateError(err))
+	}
+	rows.Close()
+
+	for i := range shelves {
+		if err := countSmartShelf(ctx, s.db, &shelves[i]); err != nil {
+			return nil, err
+		}
+	}
+	return shelves, nil
+}
+
+// GetSmartShelf finds a smart shelf by ID and counts its books
+func (s *SQLiteStore) GetSmartShelf(ctx context.Context, id int) (*models.SmartShelf, error) {
+	shelf, err := getSmartShelf(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := countSmartShelf(ctx, s.db, shelf); err != nil {
+		return nil, err
+	}
+	return shelf, nil
+}
+
+// getSmartShelf finds a smart shelf by ID using q
+func getSmartShelf(ctx context.Context, q querier, id int) (*models.SmartShelf, error) {
+	shelf, err := scanSmartShelf(q.QueryRowContext(ctx, `
+		SELECT id, name, description, filter, sort, tags, match_any_tag
+		FROM smart_shelves
+		WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, errSmartShelfNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get smart shelf %d: %w", id, translateError(err))
+	}
+	return &shelf, nil
+}
+
+// CreateSmartShelf saves a query as a smart shelf. The query is checked
+// up front so a broken shelf can never be saved.
+func (s *SQLiteStore) CreateSmartShelf(ctx context.Context, shelf models.SmartShelf) (models.SmartShelf, error) {
+	if _, err := smartShelfFilter(shelf); err != nil {
+		return models.SmartShelf{}, err
+	}
+
+	tags, err := json.Marshal(uniqueTags(shelf.Tags))
+	if err != nil {
+		return models.SmartShelf{}, fmt.Errorf("failed to encode tags: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.SmartShelf{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := checkSmartShelfName(ctx, tx, 0, shelf.Name); err != nil {
+		return models.SmartShelf{}, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO smart_shelves (name, description, filter, sort, tags, match_any_tag)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		shelf.Name, shelf.Description, shelf.Filter, shelf.Sort, string(tags), shelf.MatchAnyTag,
+	)
+	if err != nil {
+		return models.SmartShelf{}, fmt.Errorf("failed to insert smart shelf: %w", translateError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.SmartShelf{}, fmt.Errorf("failed to read new smart shelf ID: %w", translateError(err))
+	}
+
+	created, err := getSmartShelf(ctx, tx, int(id))
+	if err != nil {
+		return models.SmartShelf{}, err
+	}
+	if err := countSmartShelf(ctx, tx, created); err != nil {
+		return models.SmartShelf{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.SmartShelf{}, fmt.Errorf("failed to commit smart shelf: %w", translateError(err))
+	}
+	return *created, nil
+}
+
+// UpdateSmartShelf replaces the definition of a smart shelf
+func (s *SQLiteStore) UpdateSmartShelf(ctx context.Context, shelf models.SmartShelf) (*models.SmartShelf, error) {
+	if _, err := smartShelfFilter(shelf); err != nil {
+		return nil, err
+	}
+
+	tags, err := json.Marshal(uniqueTags(shelf.Tags))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tags: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := checkSmartShelfName(ctx, tx, shelf.ID, shelf.Name); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE smart_shelves
+		SET name = ?, description = ?, filter = ?, sort = ?, tags = ?, match_any_tag = ?
+		WHERE id = ?`,
+		shelf.Name, shelf.Description, shelf.Filter, shelf.Sort, string(tags), shelf.MatchAnyTag, shelf.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update smart shelf %d: %w", shelf.ID, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to update smart shelf %d: %w", shelf.ID, translateError(err))
+	} else if n == 0 {
+		return nil, errSmartShelfNotFound
+	}
+
+	updated, err := getSmartShelf(ctx, tx, shelf.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := countSmartShelf(ctx, tx, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit smart shelf %d: %w", shelf.ID, translateError(err))
+	}
+	return updated, nil
+}
+
+// DeleteSmartShelf removes a smart shelf
+func (s *SQLiteStore) DeleteSmartShelf(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM smart_shelves WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete smart shelf %d: %w", id, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete smart shelf %d: %w", id, translateError(err))
+	} else if n == 0 {
+		return errSmartShelfNotFound
+	}
+	return nil
+}
+
+// GetSmartShelfBooks evaluates a smart shelf. page carries the limit,
+// cursor and count options; the query itself comes from the shelf.
+func (s *SQLiteStore) GetSmartShelfBooks(ctx context.Context, id int, page models.BookFilter) (models.BookPage, error) {
+	shelf, err := getSmartShelf(ctx, s.db, id)
+	if err != nil {
+		return models.BookPage{}, err
+	}
+
+	filter, err := smartShelfFilter(*shelf)
+	if err != nil {
+		return models.BookPage{}, err
+	}
+	filter.Limit, filter.After, filter.CountTotal = page.Limit, page.After, page.CountTotal
+
+	return s.GetByFilters(ctx, filter)
+}
+
+// smartShelfFilter turns a smart shelf's saved query into a book filter,
+// checking that it parses and only uses known fields
+func smartShelfFilter(shelf models.SmartShelf) (models.BookFilter, error) {
+	filter := models.BookFilter{
+		Tags:        shelf.Tags,
+		MatchAnyTag: shelf.MatchAnyTag,
+	}
+
+	var err error
+	if filter.Where, err = filterql.Parse(shelf.Filter); err != nil {
+		return filter, err
+	}
+	if filter.Sort, err = filterql.ParseSort(shelf.Sort); err != nil {
+		return filter, err
+	}
+
+	if _, _, err := filterWhere(filter); err != nil {
+		return filter, err
+	}
+	if _, err := resolveSort(filter.Sort); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// countSmartShelf fills in how many books currently match a smart shelf
+func countSmartShelf(ctx context.Context, q querier, shelf *models.SmartShelf) error {
+	filter, err := smartShelfFilter(*shelf)
+	if err != nil {
+		return err
+	}
+
+	where, args, err := filterWhere(filter)
+	if err != nil {
+		return err
+	}
+
+	shelf.BookCount, err = countBooks(ctx, q, where, args)
+	return err
+}
+
+// checkSmartShelfName rejects a name already used by another smart shelf
+func checkSmartShelfName(ctx context.Context, q querier, id int, name string) error {
+	var existingID int
+	err := q.QueryRowContext(ctx,
+		`SELECT id FROM smart_shelves WHERE name = ? COLLATE NOCASE`, name,
+	).Scan(&existingID)
+	if err == nil && existingID != id {
+		return fmt.Errorf("smart shelf %d is already named %q: %w", existingID, name, models.ErrConflict)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check smart shelf name: %w", translateError(err))
+	}
+	return nil
+}
+
+// scanSmartShelf scans a smart_shelves row
+func scanSmartShelf(row rowScanner) (models.SmartShelf, error) {
+	var shelf models.SmartShelf
+	var tags string
+
+	err := row.Scan(&shelf.ID, &shelf.Name, &shelf.Description, &shelf.Filter, &shelf.Sort, &tags, &shelf.MatchAnyTag)
+	if err != nil {
+		return shelf, err
+	}
+
+	if err := json.Unmarshal([]byte(tags), &shelf.Tags); err != nil {
+		return shelf, fmt.Errorf("invalid tags on smart shelf %d: %w", shelf.ID, err)
+	}
+	if shelf.Tags == nil {
+		shelf.Tags = []string{}
+	}
+	return shelf, nil
+}
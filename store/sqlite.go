@@ -1,8 +1,11 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/favxlaw/models"
@@ -12,12 +15,16 @@ import (
 // SQLiteStore manages books in SQLite database
 type SQLiteStore struct {
 	db *sql.DB
+
+	// coverDir is where cover images are kept, one directory per book
+	coverDir string
 }
 
-// NewSQLiteStore creates a new SQLite store
-func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
-	// Open database
-	db, err := sql.Open("sqlite3", dbPath)
+// NewSQLiteStore creates a new SQLite store that keeps cover images
+// under coverDir
+func NewSQLiteStore(dbPath, coverDir string) (*SQLiteStore, error) {
+	// Open database with foreign keys enforced so link tables cascade
+	db, err := sql.Open("sqlite3", withForeignKeys(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -34,7 +41,16 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return &SQLiteStore{db: db}, nil
+	return &SQLiteStore{db: db, coverDir: coverDir}, nil
+}
+
+// withForeignKeys adds the driver option that turns on foreign key
+// enforcement for every pooled connection
+func withForeignKeys(dbPath string) string {
+	if strings.Contains(dbPath, "?") {
+		return dbPath + "&_foreign_keys=on"
+	}
+	return dbPath + "?_foreign_keys=on"
 }
 
 // createTable creates the books table if it doesn't exist
@@ -56,56 +72,102 @@ func createTable(db *sql.DB) error {
 }
 
 // GetAll returns all books
-func (s *SQLiteStore) GetAll() []models.Book {
+func (s *SQLiteStore) GetAll(ctx context.Context) ([]models.Book, error) {
 	query := `
-		SELECT id, title, author, status, category, notes, start_date, end_date 
+		SELECT ` + selectBookColumns("") + `
 		FROM books
 		ORDER BY id DESC
 	`
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
-		return []models.Book{} // Return empty slice on error
+		return nil, fmt.Errorf("failed to query books: %w", translateError(err))
 	}
 	defer rows.Close()
 
-	var books []models.Book
-	for rows.Next() {
-		book, err := scanBook(rows)
-		if err != nil {
-			continue // Skip invalid rows
-		}
-		books = append(books, book)
+	books, err := scanBooks(rows)
+	if err != nil {
+		return nil, err
 	}
 
-	return books
+	if err := loadBookDetails(ctx, s.db, books); err != nil {
+		return nil, err
+	}
+	return books, nil
 }
 
 // GetByID finds a book by ID
-func (s *SQLiteStore) GetByID(id int) (*models.Book, error) {
+func (s *SQLiteStore) GetByID(ctx context.Context, id int) (*models.Book, error) {
+	return getBook(ctx, s.db, id)
+}
+
+// getBook finds a book by ID using q, so it can run inside a transaction
+func getBook(ctx context.Context, q querier, id int) (*models.Book, error) {
 	query := `
-		SELECT id, title, author, status, category, notes, start_date, end_date 
+		SELECT ` + selectBookColumns("") + `
 		FROM books 
 		WHERE id = ?
 	`
 
-	row := s.db.QueryRow(query, id)
-	book, err := scanBookRow(row)
+	row := q.QueryRowContext(ctx, query, id)
+	book, err := scanBook(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("book not found")
+			return nil, errBookNotFound
 		}
+		return nil, fmt.Errorf("failed to get book %d: %w", id, translateError(err))
+	}
+
+	books := []models.Book{book}
+	if err := loadBookDetails(ctx, q, books); err != nil {
 		return nil, err
 	}
 
-	return &book, nil
+	return &books[0], nil
 }
 
-// Create adds a new book and returns it with the generated ID
-func (s *SQLiteStore) Create(book models.Book) models.Book {
+// Create adds a new book and returns it with the generated ID.
+// The book and its author credits are saved in one transaction.
+func (s *SQLiteStore) Create(ctx context.Context, book models.Book) (models.Book, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Book{}, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	created, err := insertBook(ctx, tx, book)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Book{}, fmt.Errorf("failed to commit book: %w", translateError(err))
+	}
+	return created, nil
+}
+
+// insertBook adds a book with its credits, tags and first reading inside tx
+func insertBook(ctx context.Context, tx *sql.Tx, book models.Book) (models.Book, error) {
+	credits, err := bookCredits(ctx, tx, &book)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	if err := checkWork(ctx, tx, book.WorkID, 0); err != nil {
+		return models.Book{}, err
+	}
+	if err := checkISBN(ctx, tx, 0, book.ISBN); err != nil {
+		return models.Book{}, err
+	}
+	if err := checkSeries(ctx, tx, book.SeriesID); err != nil {
+		return models.Book{}, err
+	}
+
 	query := `
-		INSERT INTO books (title, author, status, category, notes, start_date, end_date)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO books (title, author, status, category, notes, start_date, end_date, page_count, rating,
+			isbn, publisher, publication_year, language, format, duration_minutes, work_id,
+			series_id, series_position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	// Convert end_date to proper format
@@ -114,7 +176,8 @@ func (s *SQLiteStore) Create(book models.Book) models.Book {
 		endDate = book.EndDate.Format(time.RFC3339)
 	}
 
-	result, err := s.db.Exec(
+	result, err := tx.ExecContext(
+		ctx,
 		query,
 		book.Title,
 		book.Author,
@@ -123,28 +186,120 @@ func (s *SQLiteStore) Create(book models.Book) models.Book {
 		book.Notes,
 		book.StartDate.Format(time.RFC3339),
 		endDate,
+		book.PageCount,
+		book.Rating,
+		book.ISBN,
+		book.Publisher,
+		book.PublicationYear,
+		book.Language,
+		book.Format,
+		book.DurationMinutes,
+		book.WorkID,
+		book.SeriesID,
+		book.SeriesPosition,
 	)
 
 	if err != nil {
-		return book // Return original book on error
+		return models.Book{}, fmt.Errorf("failed to insert book: %w", translateError(err))
 	}
 
 	// Get the auto-generated ID
 	id, err := result.LastInsertId()
 	if err != nil {
-		return book
+		return models.Book{}, fmt.Errorf("failed to read new book ID: %w", translateError(err))
+	}
+
+	// A book that doesn't join an existing work starts its own
+	if book.WorkID == 0 {
+		book.WorkID = int(id)
+		if _, err := tx.ExecContext(ctx, `UPDATE books SET work_id = id WHERE id = ?`, id); err != nil {
+			return models.Book{}, fmt.Errorf("failed to start work for book %d: %w", id, translateError(err))
+		}
+	}
+
+	if err := linkCredits(ctx, tx, int(id), credits); err != nil {
+		return models.Book{}, err
+	}
+
+	if err := replaceTags(ctx, tx, int(id), book.Tags); err != nil {
+		return models.Book{}, err
 	}
 
 	book.ID = int(id)
-	return book
+	if err := syncReadings(ctx, tx, "", book); err != nil {
+		return models.Book{}, err
+	}
+
+	books := []models.Book{book}
+	if err := loadReadings(ctx, tx, books); err != nil {
+		return models.Book{}, err
+	}
+	book.Readings = books[0].Readings
+
+	book.Version = 1
+	book.Authors = credits
+	book.Tags = append([]string{}, uniqueTags(book.Tags)...)
+	sort.Strings(book.Tags)
+	return book, nil
 }
 
-// Update replaces a book by ID
-func (s *SQLiteStore) Update(id int, book models.Book) error {
+// Update replaces a book by ID and bumps its version. book.Version must
+// match the stored version unless it is 0, which updates unconditionally.
+func (s *SQLiteStore) Update(ctx context.Context, id int, book models.Book) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := updateBook(ctx, tx, id, book); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit book %d: %w", id, translateError(err))
+	}
+
+	return nil
+}
+
+// updateBook replaces a book and syncs its credits, tags and readings inside tx
+func updateBook(ctx context.Context, tx *sql.Tx, id int, book models.Book) error {
+	credits, err := bookCredits(ctx, tx, &book)
+	if err != nil {
+		return err
+	}
+
+	var previousStatus models.BookStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM books WHERE id = ?`, id).Scan(&previousStatus)
+	if err == sql.ErrNoRows {
+		return errBookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get book %d: %w", id, translateError(err))
+	}
+
+	// Leaving the work unset keeps the book in its own
+	if book.WorkID == 0 {
+		book.WorkID = id
+	}
+	if err := checkWork(ctx, tx, book.WorkID, id); err != nil {
+		return err
+	}
+	if err := checkISBN(ctx, tx, id, book.ISBN); err != nil {
+		return err
+	}
+	if err := checkSeries(ctx, tx, book.SeriesID); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE books 
-		SET title = ?, author = ?, status = ?, category = ?, notes = ?, start_date = ?, end_date = ?
-		WHERE id = ?
+		SET title = ?, author = ?, status = ?, category = ?, notes = ?, start_date = ?, end_date = ?,
+			page_count = ?, rating = ?, isbn = ?, publisher = ?, publication_year = ?, language = ?,
+			format = ?, duration_minutes = ?, work_id = ?, series_id = ?, series_position = ?,
+			version = version + 1
+		WHERE id = ? AND (? = 0 OR version = ?)
 	`
 
 	// Convert end_date to proper format
@@ -153,7 +308,8 @@ func (s *SQLiteStore) Update(id int, book models.Book) error {
 		endDate = book.EndDate.Format(time.RFC3339)
 	}
 
-	result, err := s.db.Exec(
+	result, err := tx.ExecContext(
+		ctx,
 		query,
 		book.Title,
 		book.Author,
@@ -162,47 +318,103 @@ func (s *SQLiteStore) Update(id int, book models.Book) error {
 		book.Notes,
 		book.StartDate.Format(time.RFC3339),
 		endDate,
+		book.PageCount,
+		book.Rating,
+		book.ISBN,
+		book.Publisher,
+		book.PublicationYear,
+		book.Language,
+		book.Format,
+		book.DurationMinutes,
+		book.WorkID,
+		book.SeriesID,
+		book.SeriesPosition,
 		id,
+		book.Version,
+		book.Version,
 	)
 
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to update book %d: %w", id, translateError(err))
 	}
 
 	// Check if any rows were affected
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to update book %d: %w", id, translateError(err))
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("book not found")
+		return missingOrStale(ctx, tx, id)
 	}
 
-	return nil
+	if err := linkCredits(ctx, tx, id, credits); err != nil {
+		return err
+	}
+
+	if book.Tags != nil {
+		if err := replaceTags(ctx, tx, id, book.Tags); err != nil {
+			return err
+		}
+	}
+
+	book.ID = id
+	return syncReadings(ctx, tx, previousStatus, book)
 }
 
-// Delete removes a book by ID
-func (s *SQLiteStore) Delete(id int) error {
-	query := `DELETE FROM books WHERE id = ?`
+// bookCredits resolves the author credits to save with a book. Explicit
+// Authors win and rewrite the display string; otherwise they are derived
+// from it.
+func bookCredits(ctx context.Context, q querier, book *models.Book) ([]models.BookAuthor, error) {
+	if len(book.Authors) == 0 {
+		return resolveCredits(ctx, q, creditsFromDisplay(book.Author))
+	}
 
-	result, err := s.db.Exec(query, id)
+	credits, err := resolveCredits(ctx, q, book.Authors)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	book.Author = creditsDisplay(credits)
+	return credits, nil
+}
+
+// Delete removes a book by ID along with its cover files. A non-zero
+// version must match the stored one.
+func (s *SQLiteStore) Delete(ctx context.Context, id int, version int) error {
+	query := `DELETE FROM books WHERE id = ? AND (? = 0 OR version = ?)`
+
+	result, err := s.db.ExecContext(ctx, query, id, version, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete book %d: %w", id, translateError(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to delete book %d: %w", id, translateError(err))
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("book not found")
+		return missingOrStale(ctx, s.db, id)
 	}
 
+	s.removeCoverFiles(id)
 	return nil
 }
 
+// missingOrStale explains why a versioned write touched no rows
+func missingOrStale(ctx context.Context, q querier, id int) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM books WHERE id = ?)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check book %d: %w", id, translateError(err))
+	}
+
+	if !exists {
+		return errBookNotFound
+	}
+	return fmt.Errorf("book %d: %w", id, models.ErrPreconditionFailed)
+}
+
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
@@ -210,13 +422,79 @@ func (s *SQLiteStore) Close() error {
 
 // Helper functions
 
-// scanBook scans a row from Rows into a Book struct
-func scanBook(rows *sql.Rows) (models.Book, error) {
+// loadBookDetails fills in the related rows of each book: credits, tags,
+// series name, read-throughs, reading session totals, the cover and the
+// current loan
+func loadBookDetails(ctx context.Context, q querier, books []models.Book) error {
+	if err := loadAuthors(ctx, q, books); err != nil {
+		return err
+	}
+	if err := loadSeriesNames(ctx, q, books); err != nil {
+		return err
+	}
+	if err := loadTags(ctx, q, books); err != nil {
+		return err
+	}
+	if err := loadReadings(ctx, q, books); err != nil {
+		return err
+	}
+	if err := loadSessionStats(ctx, q, books); err != nil {
+		return err
+	}
+	if err := loadCovers(ctx, q, books); err != nil {
+		return err
+	}
+	return loadLoans(ctx, q, books)
+}
+
+// scanBooks drains rows into a slice of books, stopping at the first error
+func scanBooks(rows *sql.Rows) ([]models.Book, error) {
+	books := []models.Book{}
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan book: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read books: %w", translateError(err))
+	}
+
+	return books, nil
+}
+
+// bookSelectColumns lists the books columns scanBook reads, in order
+var bookSelectColumns = []string{
+	"id", "title", "author", "status", "category", "notes",
+	"start_date", "end_date", "version", "page_count", "rating",
+	"isbn", "publisher", "publication_year", "language", "format", "duration_minutes", "work_id",
+	"series_id", "series_position",
+}
+
+// selectBookColumns renders bookSelectColumns for a SELECT, qualified
+// with a table alias when one is given
+func selectBookColumns(alias string) string {
+	if alias == "" {
+		return strings.Join(bookSelectColumns, ", ")
+	}
+	return alias + "." + strings.Join(bookSelectColumns, ", "+alias+".")
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanBook scans the selectBookColumns of a row into a Book struct.
+// Extra destinations receive any columns selected after them.
+func scanBook(row rowScanner, extra ...interface{}) (models.Book, error) {
 	var book models.Book
 	var startDateStr string
 	var endDateStr sql.NullString // sql.NullString handles NULL values
 
-	err := rows.Scan(
+	dest := []interface{}{
 		&book.ID,
 		&book.Title,
 		&book.Author,
@@ -225,8 +503,21 @@ func scanBook(rows *sql.Rows) (models.Book, error) {
 		&book.Notes,
 		&startDateStr,
 		&endDateStr,
-	)
+		&book.Version,
+		&book.PageCount,
+		&book.Rating,
+		&book.ISBN,
+		&book.Publisher,
+		&book.PublicationYear,
+		&book.Language,
+		&book.Format,
+		&book.DurationMinutes,
+		&book.WorkID,
+		&book.SeriesID,
+		&book.SeriesPosition,
+	}
 
+	err := row.Scan(append(dest, extra...)...)
 	if err != nil {
 		return book, err
 	}
@@ -243,84 +534,143 @@ func scanBook(rows *sql.Rows) (models.Book, error) {
 	return book, nil
 }
 
-// scanBookRow scans a single row from QueryRow
-func scanBookRow(row *sql.Row) (models.Book, error) {
-	var book models.Book
-	var startDateStr string
-	var endDateStr sql.NullString
-
-	err := row.Scan(
-		&book.ID,
-		&book.Title,
-		&book.Author,
-		&book.Status,
-		&book.Category,
-		&book.Notes,
-		&startDateStr,
-		&endDateStr,
-	)
+// GetByFilters returns one page of books matching the provided filters.
+// Pages are seeked with the cursor instead of OFFSET, so only the rows of
+// the requested page are ever read.
+func (s *SQLiteStore) GetByFilters(ctx context.Context, filter models.BookFilter) (models.BookPage, error) {
+	where, args, err := filterWhere(filter)
+	if err != nil {
+		return models.BookPage{}, err
+	}
 
+	terms, err := resolveSort(filter.Sort)
 	if err != nil {
-		return book, err
+		return models.BookPage{}, err
 	}
+	sortBy := sortKeyString(filter.Sort)
 
-	// Parse dates
-	book.StartDate, _ = time.Parse(time.RFC3339, startDateStr)
+	page := models.BookPage{Books: []models.Book{}}
 
-	if endDateStr.Valid {
-		endDate, _ := time.Parse(time.RFC3339, endDateStr.String)
-		book.EndDate = &endDate
+	if filter.CountTotal {
+		total, err := countBooks(ctx, s.db, where, args)
+		if err != nil {
+			return page, err
+		}
+		page.Total = &total
 	}
 
-	return book, nil
-}
+	// Seek past the last book of the previous page
+	if filter.After != nil {
+		if filter.After.SortBy != sortBy {
+			return page, models.NewValidationError("cursor", "cursor does not match the requested sort")
+		}
+
+		seek, err := seekClause(terms, filter.After.Values, &args)
+		if err != nil {
+			return page, err
+		}
+		where += ` AND ` + seek
+	}
 
-// GetByFilters returns books matching the provided filters
-func (s *SQLiteStore) GetByFilters(status, category, sortBy string) []models.Book {
 	query := `
-		SELECT id, title, author, status, category, notes, start_date, end_date 
+		SELECT ` + selectBookColumns("") + `
 		FROM books
-		WHERE 1=1
-	`
+	` + where + orderByClause(terms)
+
+	// Fetch one extra row to learn whether another page follows
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit+1)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return page, fmt.Errorf("failed to query books: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		return page, err
+	}
+
+	if err := loadBookDetails(ctx, s.db, books); err != nil {
+		return page, err
+	}
+
+	if filter.Limit > 0 && len(books) > filter.Limit {
+		books = books[:filter.Limit]
+		page.Next = &models.Cursor{
+			SortBy: sortBy,
+			Values: cursorValues(terms, books[len(books)-1]),
+		}
+	}
+
+	page.Books = books
+	return page, nil
+}
+
+// filterWhere renders the WHERE clause selecting the books that match a
+// filter, ignoring its sort and pagination
+func filterWhere(filter models.BookFilter) (string, []interface{}, error) {
+	where := ` WHERE 1=1`
 	args := []interface{}{}
 
 	// Add filters
-	if status != "" {
-		query += ` AND status = ?`
-		args = append(args, status)
+	if filter.Status != "" {
+		where += ` AND status = ?`
+		args = append(args, filter.Status)
 	}
 
-	if category != "" {
-		query += ` AND category = ?`
-		args = append(args, category)
+	if filter.Category != "" {
+		where += ` AND category = ?`
+		args = append(args, filter.Category)
 	}
 
-	// Add sorting
-	switch sortBy {
-	case "title":
-		query += ` ORDER BY title ASC`
-	case "author":
-		query += ` ORDER BY author ASC`
-	case "date":
-		query += ` ORDER BY start_date DESC`
-	default:
-		query += ` ORDER BY id DESC`
+	if tags := uniqueTags(filter.Tags); len(tags) > 0 {
+		where += ` AND ` + tagCondition(tags, filter.MatchAnyTag, &args)
 	}
 
-	rows, err := s.db.Query(query, args...)
-	if err != nil {
-		return []models.Book{}
+	if filter.ISBN != "" {
+		where += ` AND isbn = ?`
+		args = append(args, filter.ISBN)
 	}
-	defer rows.Close()
 
-	var books []models.Book
-	for rows.Next() {
-		book, err := scanBook(rows)
+	if filter.SeriesID != 0 {
+		where += ` AND series_id = ?`
+		args = append(args, filter.SeriesID)
+	}
+
+	if filter.MinRating != nil {
+		where += ` AND rating >= ?`
+		args = append(args, *filter.MinRating)
+	}
+
+	if filter.OnLoan != nil {
+		onLoan := `EXISTS (SELECT 1 FROM loans WHERE loans.book_id = books.id AND returned_date IS NULL)`
+		if !*filter.OnLoan {
+			onLoan = `NOT ` + onLoan
+		}
+		where += ` AND ` + onLoan
+	}
+
+	if filter.Where != nil {
+		condition, err := compileFilter(filter.Where, &args)
 		if err != nil {
-			continue
+			return "", nil, err
 		}
-		books = append(books, book)
+		where += ` AND ` + condition
 	}
 
-	return books
+	return where, args, nil
+}
+
+// countBooks counts the books matching a WHERE clause from filterWhere
+func countBooks(ctx context.Context, q querier, where string, args []interface{}) (int, error) {
+	var total int
+	err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM books`+where, args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count books: %w", translateError(err))
+	}
+	return total, nil
 }
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Migrations log every step
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestStore opens a migrated store in a temporary directory. Tests
+// using it are skipped when the driver was built without FTS5.
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	dir := t.TempDir()
+	s, err := NewSQLiteStore(filepath.Join(dir, "test.db"), filepath.Join(dir, "covers"))
+	if errors.Is(err, ErrNoFTS5) {
+		t.Skip("needs -tags sqlite_fts5")
+	}
+	if err != nil {
+		t.Fatalf("NewSQLiteStore error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
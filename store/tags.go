@@ -0,0 +1,406 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/favxlaw/models"
+)
+
+// errTagNotFound is returned when no tag matches the requested ID
+var errTagNotFound = fmt.Errorf("tag %w", models.ErrNotFound)
+
+// normalizeTag lowercases a tag and collapses its whitespace so
+// "Sci Fi" and "sci  fi" are the same tag
+func normalizeTag(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// ensureTag returns the ID of the named tag, creating it if needed
+func ensureTag(ctx context.Context, q querier, name string) (int, error) {
+	name = normalizeTag(name)
+	if name == "" {
+		return 0, models.NewValidationError("Tags", "tag name is required")
+	}
+
+	_, err := q.ExecContext(ctx, `INSERT INTO tags (name) VALUES (?) ON CONFLICT(name) DO NOTHING`, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save tag: %w", translateError(err))
+	}
+
+	var id int
+	err = q.QueryRowContext(ctx, `SELECT id FROM tags WHERE name = ?`, name).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find tag: %w", translateError(err))
+	}
+	return id, nil
+}
+
+// addTags attaches tags to a book, ignoring ones it already has
+func addTags(ctx context.Context, q querier, bookID int, names []string) error {
+	for _, name := range names {
+		tagID, err := ensureTag(ctx, q, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx,
+			`INSERT OR IGNORE INTO book_tags (book_id, tag_id) VALUES (?, ?)`,
+			bookID, tagID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to tag book %d: %w", bookID, translateError(err))
+		}
+	}
+	return nil
+}
+
+// replaceTags sets a book's tags to exactly names
+func replaceTags(ctx context.Context, q querier, bookID int, names []string) error {
+	_, err := q.ExecContext(ctx, `DELETE FROM book_tags WHERE book_id = ?`, bookID)
+	if err != nil {
+		return fmt.Errorf("failed to clear tags of book %d: %w", bookID, translateError(err))
+	}
+	return addTags(ctx, q, bookID, names)
+}
+
+// loadTags fills in the Tags of each book with one query
+func loadTags(ctx context.Context, q querier, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(books))
+	args := make([]interface{}, len(books))
+	for i := range books {
+		index[books[i].ID] = i
+		args[i] = books[i].ID
+		books[i].Tags = []string{}
+	}
+
+	query := `
+		SELECT bt.book_id, t.name
+		FROM book_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE bt.book_id IN (` + placeholders(len(books)) + `)
+		ORDER BY bt.book_id, t.name
+	`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var name string
+		if err := rows.Scan(&bookID, &name); err != nil {
+			return fmt.Errorf("failed to scan tag: %w", err)
+		}
+		i := index[bookID]
+		books[i].Tags = append(books[i].Tags, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read tags: %w", translateError(err))
+	}
+	return nil
+}
+
+// tagCondition builds the WHERE condition for a tag filter over
+// normalized, distinct names. A book needs every tag unless matchAny is set.
+func tagCondition(names []string, matchAny bool, args *[]interface{}) string {
+	condition := `id IN (
+		SELECT bt.book_id FROM book_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE t.name IN (` + placeholders(len(names)) + `)`
+
+	for _, name := range names {
+		*args = append(*args, name)
+	}
+
+	if !matchAny {
+		condition += ` GROUP BY bt.book_id HAVING COUNT(*) = ?`
+		*args = append(*args, len(names))
+	}
+	return condition + `)`
+}
+
+// uniqueTags normalizes and deduplicates tag names
+func uniqueTags(names []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// AddBookTags tags a book and returns its full tag list
+func (s *SQLiteStore) AddBookTags(ctx context.Context, bookID int, names []string) ([]string, error) {
+	return s.changeBookTags(ctx, bookID, func(tx *sql.Tx) error {
+		return addTags(ctx, tx, bookID, names)
+	})
+}
+
+// RemoveBookTag untags a book and returns its remaining tags
+func (s *SQLiteStore) RemoveBookTag(ctx context.Context, bookID int, name string) ([]string, error) {
+	return s.changeBookTags(ctx, bookID, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			`DELETE FROM book_tags WHERE book_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)`,
+			bookID, normalizeTag(name),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to untag book %d: %w", bookID, translateError(err))
+		}
+
+		if n, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to untag book %d: %w", bookID, translateError(err))
+		} else if n == 0 {
+			return fmt.Errorf("tag %q on book %d: %w", name, bookID, models.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+// changeBookTags runs a tag change on an existing book, bumps the book's
+// version so its ETag changes, and returns the resulting tags
+func (s *SQLiteStore) changeBookTags(ctx context.Context, bookID int, change func(tx *sql.Tx) error) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE books SET version = version + 1 WHERE id = ?`, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update book %d: %w", bookID, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to update book %d: %w", bookID, translateError(err))
+	} else if n == 0 {
+		return nil, errBookNotFound
+	}
+
+	if err := change(tx); err != nil {
+		return nil, err
+	}
+
+	books := []models.Book{{ID: bookID}}
+	if err := loadTags(ctx, tx, books); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tags: %w", translateError(err))
+	}
+	return books[0].Tags, nil
+}
+
+// ListTags returns every tag with how many books carry it, by name
+func (s *SQLiteStore) ListTags(ctx context.Context) ([]models.Tag, error) {
+	query := `
+		SELECT t.id, t.name, COUNT(bt.book_id)
+		FROM tags t
+		LEFT JOIN book_tags bt ON bt.tag_id = t.id
+		GROUP BY t.id
+		ORDER BY t.name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.BookCount); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", translateError(err))
+	}
+	return tags, nil
+}
+
+// getTag finds a tag by ID using q
+func getTag(ctx context.Context, q querier, id int) (*models.Tag, error) {
+	query := `
+		SELECT t.id, t.name, COUNT(bt.book_id)
+		FROM tags t
+		LEFT JOIN book_tags bt ON bt.tag_id = t.id
+		WHERE t.id = ?
+		GROUP BY t.id
+	`
+
+	var tag models.Tag
+	err := q.QueryRowContext(ctx, query, id).Scan(&tag.ID, &tag.Name, &tag.BookCount)
+	if err == sql.ErrNoRows {
+		return nil, errTagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag %d: %w", id, translateError(err))
+	}
+	return &tag, nil
+}
+
+// RenameTag changes a tag's name. Renaming onto an existing tag is a
+// conflict; merge the two tags instead.
+func (s *SQLiteStore) RenameTag(ctx context.Context, id int, name string) (*models.Tag, error) {
+	name = normalizeTag(name)
+	if name == "" {
+		return nil, models.NewValidationError("Name", "name is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	var existingID int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE name = ?`, name).Scan(&existingID)
+	if err == nil && existingID != id {
+		return nil, fmt.Errorf("tag %d is already named %q, merge instead: %w", existingID, name, models.ErrConflict)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check tag name: %w", translateError(err))
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE tags SET name = ? WHERE id = ?`, name, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename tag %d: %w", id, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to rename tag %d: %w", id, translateError(err))
+	} else if n == 0 {
+		return nil, errTagNotFound
+	}
+
+	if err := touchTaggedBooks(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	tag, err := getTag(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rename: %w", translateError(err))
+	}
+	return tag, nil
+}
+
+// MergeTags folds the source tags into targetID and deletes them
+func (s *SQLiteStore) MergeTags(ctx context.Context, targetID int, sourceIDs []int) (*models.Tag, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if _, err := getTag(ctx, tx, targetID); err != nil {
+		return nil, err
+	}
+
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			return nil, models.NewValidationError("TagIDs", "cannot merge a tag into itself")
+		}
+		if _, err := getTag(ctx, tx, sourceID); err != nil {
+			return nil, err
+		}
+
+		if err := touchTaggedBooks(ctx, tx, sourceID); err != nil {
+			return nil, err
+		}
+
+		// Books that already carry the target keep a single link
+		_, err := tx.ExecContext(ctx,
+			`UPDATE OR IGNORE book_tags SET tag_id = ? WHERE tag_id = ?`,
+			targetID, sourceID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move tag %d: %w", sourceID, translateError(err))
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM book_tags WHERE tag_id = ?`, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear tag %d: %w", sourceID, translateError(err))
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete tag %d: %w", sourceID, translateError(err))
+		}
+	}
+
+	tag, err := getTag(ctx, tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", translateError(err))
+	}
+	return tag, nil
+}
+
+// DeleteTag removes a tag from every book and deletes it
+func (s *SQLiteStore) DeleteTag(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback()
+
+	if err := touchTaggedBooks(ctx, tx, id); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM book_tags WHERE tag_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to clear tag %d: %w", id, translateError(err))
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag %d: %w", id, translateError(err))
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete tag %d: %w", id, translateError(err))
+	} else if n == 0 {
+		return errTagNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tag delete: %w", translateError(err))
+	}
+	return nil
+}
+
+// touchTaggedBooks bumps the version of every book carrying a tag, since
+// their representation changes with it
+func touchTaggedBooks(ctx context.Context, q querier, tagID int) error {
+	_, err := q.ExecContext(ctx,
+		`UPDATE books SET version = version + 1 WHERE id IN (SELECT book_id FROM book_tags WHERE tag_id = ?)`,
+		tagID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update books tagged %d: %w", tagID, translateError(err))
+	}
+	return nil
+}